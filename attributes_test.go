@@ -0,0 +1,180 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func testInventory() *Inventory {
+	fragile := "fragile"
+	return &Inventory{
+		ID:       "1",
+		Counts:   map[string]int{"boxes": 3, "crates": 0},
+		Readings: map[string][]float64{"temperature": {18.5, 19}},
+		Labels:   map[int]string{1: "one", 20: "twenty"},
+		Sizes:    map[string]*Dimension{"small": {Width: 1, Height: 2}, "none": nil},
+		Tags:     []*string{&fragile, nil},
+		Grid:     [2][2]int{{1, 2}, {3, 4}},
+		Extra: map[string]interface{}{
+			"shelves": []interface{}{"a", float64(2), true, nil},
+		},
+		Raw:    json.RawMessage(`{"any":["json"]}`),
+		Blob:   []byte("binary"),
+		Items:  []*Item{{SKU: "A1", Quantity: 2}, {SKU: "B2", Quantity: 5}},
+		Origin: &Location{Latitude: 51.5, Longitude: -0.12},
+	}
+}
+
+func TestCompositeAttributesRoundTrip(t *testing.T) {
+	in := testInventory()
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, in); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := new(Inventory)
+	if err := jsonapi.UnmarshalPayload(out, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(in, decoded) {
+		t.Fatalf("Was expecting %#v, got %#v", in, decoded)
+	}
+}
+
+func TestUnmarshalCompositeAttributes_nulls(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "inventories",
+			"id": "1",
+			"attributes": {
+				"counts": null,
+				"tags": ["a", null],
+				"extra": null,
+				"items": [{"sku": "A1", "quantity": 1}, null]
+			}
+		}
+	}`
+
+	out := new(Inventory)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Counts != nil {
+		t.Fatalf("Was expecting counts to be nil, got %v", out.Counts)
+	}
+	if len(out.Tags) != 2 || *out.Tags[0] != "a" || out.Tags[1] != nil {
+		t.Fatalf("Was expecting tags [a <nil>], got %v", out.Tags)
+	}
+	if out.Extra != nil {
+		t.Fatalf("Was expecting extra to be nil, got %v", out.Extra)
+	}
+	if len(out.Items) != 2 || out.Items[0].SKU != "A1" || out.Items[1] != nil {
+		t.Fatalf("Was expecting items [A1 <nil>], got %v", out.Items)
+	}
+}
+
+func TestUnmarshalCompositeAttributes_errors(t *testing.T) {
+	for name, attributes := range map[string]string{
+		"map value":    `{"counts": {"boxes": "three"}}`,
+		"map key":      `{"labels": {"one": "one"}}`,
+		"array length": `{"grid": [[1, 2], [3]]}`,
+		"slice value":  `{"tags": ["a", 1]}`,
+		"struct slice": `{"items": [{"sku": "A1"}, {"sku": 2}]}`,
+		"struct value": `{"items": ["A1"]}`,
+	} {
+		in := `{"data": {"type": "inventories", "id": "1", "attributes": ` + attributes + `}}`
+
+		err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Inventory))
+		if err == nil {
+			t.Fatalf("Was expecting an error for %s", name)
+		}
+	}
+}
+
+func TestMarshalNestedStructAttributes(t *testing.T) {
+	hired := time.Date(2016, 8, 17, 8, 27, 12, 0, time.UTC)
+	company := &Company{
+		ID:   "1",
+		Name: "Planet Express",
+		Boss: Employee{Firstname: "Hubert", Surname: "Farnsworth", Age: 160},
+		Teams: []Team{{
+			Name:    "Delivery",
+			Leader:  &Employee{Firstname: "Leela", HiredAt: &hired},
+			Members: []Employee{{Firstname: "Fry"}},
+		}},
+		FoundedAt: time.Date(2961, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, company); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+		t.Fatal(err)
+	}
+	attributes := jsonData["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+
+	boss := attributes["boss"].(map[string]interface{})
+	if e, a := "Hubert", boss["firstname"]; e != a {
+		t.Fatalf("Was expecting boss firstname `%s`, got `%v`", e, a)
+	}
+	if hiredAt, ok := boss["hired-at"]; !ok || hiredAt != nil {
+		t.Fatalf("Was expecting boss hired-at to be null, got %v", hiredAt)
+	}
+
+	leader := attributes["teams"].([]interface{})[0].(map[string]interface{})["leader"].(map[string]interface{})
+	if e, a := "2016-08-17T08:27:12Z", leader["hired-at"]; e != a {
+		t.Fatalf("Was expecting leader hired-at `%s`, got `%v`", e, a)
+	}
+
+	decoded := new(Company)
+	if err := jsonapi.UnmarshalPayload(out, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(company, decoded) {
+		t.Fatalf("Was expecting %#v, got %#v", company, decoded)
+	}
+}
+
+func TestMarshalNestedStructAttributes_omitempty(t *testing.T) {
+	inventory := &Inventory{
+		ID:     "1",
+		Sizes:  map[string]*Dimension{"flat": {Width: 3}},
+		Origin: &Location{Latitude: 1, Longitude: 2},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, inventory); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+		t.Fatal(err)
+	}
+	attributes := jsonData["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+
+	flat := attributes["sizes"].(map[string]interface{})["flat"].(map[string]interface{})
+	if _, ok := flat["height"]; ok {
+		t.Fatal("Was expecting height to be omitted")
+	}
+	if e, a := float64(3), flat["width"]; e != a {
+		t.Fatalf("Was expecting width %v, got %v", e, a)
+	}
+
+	origin := attributes["origin"].(map[string]interface{})
+	if e, a := float64(1), origin["lat"]; e != a {
+		t.Fatalf("Was expecting origin to use json tags, got %v", origin)
+	}
+}
@@ -0,0 +1,300 @@
+// Package client is a typed HTTP client for JSON API servers. Models are
+// the same structs, with jsonapi tags, that the jsonapi package marshals and
+// unmarshals.
+//
+//	c := client.New("https://example.com/api")
+//
+//	blog := new(Blog)
+//	err := c.Get(ctx, "/blogs/1", blog, &client.Query{Include: []string{"posts"}})
+//
+//	var blogs []*Blog
+//	err = c.List(ctx, "/blogs", &blogs, &client.Query{Sort: []string{"-created_at"}})
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+// Client sends JSON API requests. The zero value is usable with absolute
+// URLs.
+type Client struct {
+	// BaseURL is prepended to request URLs that are not absolute.
+	BaseURL string
+
+	// HTTPClient sends the requests; http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+
+	// Header is added to every request, e.g. for authorization.
+	Header http.Header
+
+	// Options are passed to the jsonapi.Decoder of every response, after
+	// the client's defaults. Responses are decoded without limits, hooks or
+	// validation, which are meant for untrusted request bodies; pass e.g.
+	// jsonapi.WithLimits or jsonapi.WithChecks(true) to turn them back on.
+	Options []jsonapi.Option
+}
+
+// New creates a Client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Error is returned when the server responds with a status other than 2xx.
+// Errors holds the error objects of the response's errors document, if it
+// had one.
+type Error struct {
+	StatusCode int
+	Errors     []*jsonapi.ErrorObject
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("jsonapi client: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	details := make([]string, len(e.Errors))
+	for i, obj := range e.Errors {
+		details[i] = strings.TrimSpace(obj.Title + " " + obj.Detail)
+	}
+
+	return fmt.Sprintf("jsonapi client: %d %s: %s",
+		e.StatusCode, http.StatusText(e.StatusCode), strings.Join(details, "; "))
+}
+
+// Query holds the JSON API query parameters of a request.
+type Query struct {
+	// Include lists the relationship paths to include, e.g. "posts.comments".
+	Include []string
+
+	// Fields lists the sparse fieldsets to request, keyed by resource type.
+	Fields map[string][]string
+
+	// Sort lists the sort fields, prefixed with "-" for descending order.
+	Sort []string
+
+	// Filter holds the filter parameters, e.g. "author" for filter[author].
+	Filter map[string]string
+
+	// Page holds the pagination parameters, keyed by either their full name,
+	// e.g. jsonapi.QueryParamPageNumber, or their bare one, e.g. "number".
+	Page map[string]string
+}
+
+// Values encodes the query as URL query parameters.
+func (q *Query) Values() url.Values {
+	values := url.Values{}
+	if q == nil {
+		return values
+	}
+
+	if len(q.Include) > 0 {
+		values.Set("include", strings.Join(q.Include, ","))
+	}
+	for _, resourceType := range sortedKeys(q.Fields) {
+		values.Set(fmt.Sprintf("fields[%s]", resourceType), strings.Join(q.Fields[resourceType], ","))
+	}
+	if len(q.Sort) > 0 {
+		values.Set("sort", strings.Join(q.Sort, ","))
+	}
+	for key, value := range q.Filter {
+		values.Set(fmt.Sprintf("filter[%s]", key), value)
+	}
+	for key, value := range q.Page {
+		if !strings.HasPrefix(key, "page[") {
+			key = fmt.Sprintf("page[%s]", key)
+		}
+		values.Set(key, value)
+	}
+
+	return values
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Get fetches the resource at url into model, a struct pointer.
+func (c *Client) Get(ctx context.Context, url string, model interface{}, query *Query) error {
+	return c.do(ctx, http.MethodGet, url, query, nil, model)
+}
+
+// List fetches the collection at url into models, a pointer to a slice of
+// struct pointers.
+func (c *Client) List(ctx context.Context, url string, models interface{}, query *Query) error {
+	return c.do(ctx, http.MethodGet, url, query, nil, models)
+}
+
+// Create POSTs model, a struct pointer, to the collection at url. If the
+// server responds with the created resource, it is unmarshalled into model,
+// e.g. to set a server assigned ID.
+func (c *Client) Create(ctx context.Context, url string, model interface{}) error {
+	return c.do(ctx, http.MethodPost, url, nil, model, model)
+}
+
+// Update PATCHes the resource at url with model, a struct pointer. If the
+// server responds with the updated resource, it is unmarshalled into model.
+func (c *Client) Update(ctx context.Context, url string, model interface{}) error {
+	return c.do(ctx, http.MethodPatch, url, nil, model, model)
+}
+
+// Delete deletes the resource at url.
+func (c *Client) Delete(ctx context.Context, url string) error {
+	return c.do(ctx, http.MethodDelete, url, nil, nil, nil)
+}
+
+// do sends a request with body, if not nil, marshalled as its document, and
+// unmarshals the response document into out, if not nil.
+func (c *Client) do(ctx context.Context, method, url string, query *Query, body, out interface{}) error {
+	resp, err := c.send(ctx, method, url, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, out, c.options(ctx))
+}
+
+// options returns the options of the decoders of the client's responses.
+func (c *Client) options(ctx context.Context) []jsonapi.Option {
+	opts := []jsonapi.Option{
+		jsonapi.WithContext(ctx),
+		jsonapi.WithLimits(jsonapi.Limits{}),
+		jsonapi.WithChecks(false),
+	}
+
+	return append(opts, c.Options...)
+}
+
+// send sends a request and returns the response, or an *Error if its status
+// is not 2xx.
+func (c *Client) send(ctx context.Context, method, url string, query *Query, body interface{}) (*http.Response, error) {
+	var in io.Reader
+	if body != nil {
+		buf := bytes.NewBuffer(nil)
+		if err := jsonapi.NewEncoder(buf, jsonapi.WithIncludeMode(jsonapi.IncludeNone)).Encode(body); err != nil {
+			return nil, err
+		}
+		in = buf
+	}
+
+	target, err := c.resolve(url)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, target, in)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if values := query.Values(); len(values) > 0 {
+		if req.URL.RawQuery != "" {
+			req.URL.RawQuery += "&"
+		}
+		req.URL.RawQuery += values.Encode()
+	}
+
+	for key, values := range c.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Accept", jsonapi.MediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp, c.options(ctx))
+	}
+
+	return resp, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return http.DefaultClient
+}
+
+// resolve resolves ref against the BaseURL unless it is absolute. Paths are
+// relative to the BaseURL's path, with or without a leading slash.
+func (c *Client) resolve(ref string) (string, error) {
+	target, err := url.Parse(ref)
+	if err != nil || c.BaseURL == "" || target.IsAbs() || target.Host != "" {
+		return ref, err
+	}
+
+	base, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		base.RawPath = ""
+	}
+	target.Path = strings.TrimPrefix(target.Path, "/")
+	target.RawPath = strings.TrimPrefix(target.RawPath, "/")
+
+	return base.ResolveReference(target).String(), nil
+}
+
+// decodeResponse unmarshals the document of a successful response into out.
+// Responses without a body, such as 204 No Content, or whose document has no
+// primary data, such as a meta-only 202 Accepted, leave out untouched.
+func decodeResponse(resp *http.Response, out interface{}, opts []jsonapi.Option) error {
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	_, err = jsonapi.NewDecoder(bytes.NewReader(data), opts...).DecodeWithTopLevel(out)
+	if err == jsonapi.ErrNoData {
+		return nil
+	}
+
+	return err
+}
+
+// decodeError builds the *Error of a failed response, with the error
+// objects of its errors document if it has one.
+func decodeError(resp *http.Response, opts []jsonapi.Option) error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	if errs, err := jsonapi.NewDecoder(resp.Body, opts...).DecodeErrors(); err == nil {
+		apiErr.Errors = errs
+	}
+
+	return apiErr
+}
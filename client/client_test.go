@@ -0,0 +1,325 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+	"github.com/cheeryfella/jsonapi/client"
+)
+
+type Blog struct {
+	ID    string  `jsonapi:"primary,blogs"`
+	Title string  `jsonapi:"attr,title"`
+	Posts []*Post `jsonapi:"relation,posts,omitempty"`
+}
+
+type Author struct {
+	ID   string `jsonapi:"primary,authors"`
+	Name string `jsonapi:"attr,name,required"`
+}
+
+type Post struct {
+	ID    string `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+}
+
+// recorded is what a test server saw of a request.
+type recorded struct {
+	method      string
+	path        string
+	query       url.Values
+	accept      string
+	contentType string
+	body        map[string]interface{}
+}
+
+func newServer(t *testing.T, status int, response string, rec *recorded) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.method = r.Method
+		rec.path = r.URL.Path
+		rec.query = r.URL.Query()
+		rec.accept = r.Header.Get("Accept")
+		rec.contentType = r.Header.Get("Content-Type")
+
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &rec.body); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		w.Header().Set("Content-Type", jsonapi.MediaType)
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+}
+
+func TestClientGet(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusOK, `{
+		"data": {
+			"type": "blogs",
+			"id": "1",
+			"attributes": {"title": "Title 1"},
+			"relationships": {"posts": {"data": [{"type": "posts", "id": "2"}]}}
+		},
+		"included": [{"type": "posts", "id": "2", "attributes": {"title": "Post 2"}}]
+	}`, rec)
+	defer server.Close()
+
+	blog := new(Blog)
+	query := &client.Query{
+		Include: []string{"posts", "posts.comments"},
+		Fields:  map[string][]string{"blogs": {"title", "posts"}},
+		Sort:    []string{"-created_at", "title"},
+		Filter:  map[string]string{"author": "9"},
+		Page:    map[string]string{jsonapi.QueryParamPageNumber: "2", "size": "10"},
+	}
+	if err := client.New(server.URL).Get(context.Background(), "/blogs/1", blog, query); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.method != http.MethodGet || rec.path != "/blogs/1" {
+		t.Fatalf("Was expecting GET /blogs/1, got %s %s", rec.method, rec.path)
+	}
+	if rec.accept != jsonapi.MediaType {
+		t.Fatalf("Was expecting Accept %q, got %q", jsonapi.MediaType, rec.accept)
+	}
+
+	expected := map[string]string{
+		"include":        "posts,posts.comments",
+		"fields[blogs]":  "title,posts",
+		"sort":           "-created_at,title",
+		"filter[author]": "9",
+		"page[number]":   "2",
+		"page[size]":     "10",
+	}
+	for key, value := range expected {
+		if got := rec.query.Get(key); got != value {
+			t.Fatalf("Was expecting %s=%q, got %q", key, value, got)
+		}
+	}
+
+	if blog.ID != "1" || blog.Title != "Title 1" {
+		t.Fatalf("Was expecting blog 1, got %#v", blog)
+	}
+	if len(blog.Posts) != 1 || blog.Posts[0].Title != "Post 2" {
+		t.Fatalf("Was expecting the included post, got %#v", blog.Posts)
+	}
+}
+
+func TestClientList(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusOK, `{
+		"data": [
+			{"type": "blogs", "id": "1", "attributes": {"title": "Title 1"}},
+			{"type": "blogs", "id": "2", "attributes": {"title": "Title 2"}}
+		]
+	}`, rec)
+	defer server.Close()
+
+	var blogs []*Blog
+	if err := client.New(server.URL).List(context.Background(), "/blogs", &blogs, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.query) != 0 {
+		t.Fatalf("Was expecting no query parameters, got %v", rec.query)
+	}
+	if len(blogs) != 2 || blogs[1].Title != "Title 2" {
+		t.Fatalf("Was expecting two blogs, got %#v", blogs)
+	}
+}
+
+func TestClientCreate(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusCreated, `{
+		"data": {"type": "blogs", "id": "10", "attributes": {"title": "New"}}
+	}`, rec)
+	defer server.Close()
+
+	blog := &Blog{Title: "New", Posts: []*Post{{ID: "2", Title: "Post 2"}}}
+	if err := client.New(server.URL).Create(context.Background(), "/blogs", blog); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.method != http.MethodPost {
+		t.Fatalf("Was expecting POST, got %s", rec.method)
+	}
+	if rec.contentType != jsonapi.MediaType {
+		t.Fatalf("Was expecting Content-Type %q, got %q", jsonapi.MediaType, rec.contentType)
+	}
+	if _, ok := rec.body["included"]; ok {
+		t.Fatal("Was expecting the request document not to include related resources")
+	}
+	data := rec.body["data"].(map[string]interface{})
+	if data["attributes"].(map[string]interface{})["title"] != "New" {
+		t.Fatalf("Was expecting the title to be sent, got %v", data)
+	}
+
+	if blog.ID != "10" {
+		t.Fatalf("Was expecting the server assigned ID, got %q", blog.ID)
+	}
+}
+
+func TestClientUpdateNoContent(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusNoContent, "", rec)
+	defer server.Close()
+
+	blog := &Blog{ID: "1", Title: "Updated"}
+	if err := client.New(server.URL).Update(context.Background(), "/blogs/1", blog); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.method != http.MethodPatch || rec.path != "/blogs/1" {
+		t.Fatalf("Was expecting PATCH /blogs/1, got %s %s", rec.method, rec.path)
+	}
+	if blog.Title != "Updated" {
+		t.Fatalf("Was expecting the model to be untouched, got %#v", blog)
+	}
+}
+
+func TestClientMetaOnly(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusAccepted} {
+		rec := new(recorded)
+		server := newServer(t, status, `{"meta": {"job": "queued"}}`, rec)
+
+		blog := &Blog{Title: "New"}
+		if err := client.New(server.URL).Create(context.Background(), "/blogs", blog); err != nil {
+			t.Fatalf("Was expecting a %d meta-only response to succeed, got %v", status, err)
+		}
+		if blog.ID != "" || blog.Title != "New" {
+			t.Fatalf("Was expecting the model to be untouched, got %#v", blog)
+		}
+
+		server.Close()
+	}
+}
+
+func TestClientDelete(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusNoContent, "", rec)
+	defer server.Close()
+
+	if err := client.New(server.URL).Delete(context.Background(), "/blogs/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.method != http.MethodDelete {
+		t.Fatalf("Was expecting DELETE, got %s", rec.method)
+	}
+	if rec.contentType != "" {
+		t.Fatalf("Was expecting no Content-Type without a body, got %q", rec.contentType)
+	}
+}
+
+func TestClientErrorDocument(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusUnprocessableEntity, `{
+		"errors": [
+			{"status": "422", "title": "Invalid Attribute", "detail": "Title is required", "source": {"pointer": "/data/attributes/title"}}
+		]
+	}`, rec)
+	defer server.Close()
+
+	err := client.New(server.URL).Create(context.Background(), "/blogs", &Blog{})
+
+	apiErr, ok := err.(*client.Error)
+	if !ok {
+		t.Fatalf("Was expecting a *client.Error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Was expecting status 422, got %d", apiErr.StatusCode)
+	}
+	if len(apiErr.Errors) != 1 || apiErr.Errors[0].Source.Pointer != "/data/attributes/title" {
+		t.Fatalf("Was expecting the error object, got %#v", apiErr.Errors)
+	}
+	if apiErr.Error() != "jsonapi client: 422 Unprocessable Entity: Invalid Attribute Title is required" {
+		t.Fatalf("Unexpected error message %q", apiErr.Error())
+	}
+}
+
+func TestClientErrorWithoutDocument(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusNotFound, "not found", rec)
+	defer server.Close()
+
+	blog := new(Blog)
+	err := client.New(server.URL).Get(context.Background(), "/blogs/1", blog, nil)
+
+	if apiErr, ok := err.(*client.Error); !ok || apiErr.StatusCode != http.StatusNotFound || len(apiErr.Errors) != 0 {
+		t.Fatalf("Was expecting a 404 *client.Error without error objects, got %v", err)
+	}
+}
+
+func TestClientContextCanceled(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusOK, `{"data": null}`, rec)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.New(server.URL).Get(ctx, "/blogs/1", new(Blog), nil)
+	if urlErr, ok := err.(*url.Error); !ok || urlErr.Err != context.Canceled {
+		t.Fatalf("Was expecting context.Canceled, got %v", err)
+	}
+}
+
+func TestClientResolve(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusOK, `{"data": {"type": "blogs", "id": "1"}}`, rec)
+	defer server.Close()
+
+	tests := map[string]struct {
+		base, url, path string
+	}{
+		"leading slash":    {server.URL + "/api", "/blogs/1", "/api/blogs/1"},
+		"relative":         {server.URL + "/api/", "blogs/1", "/api/blogs/1"},
+		"URL in the query": {server.URL + "/api", "/blogs/1?next=http://example.com", "/api/blogs/1"},
+		"absolute":         {"http://example.com/api", server.URL + "/blogs/1", "/blogs/1"},
+	}
+
+	for name, test := range tests {
+		c := &client.Client{BaseURL: test.base}
+		if err := c.Get(context.Background(), test.url, new(Blog), nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if rec.path != test.path {
+			t.Fatalf("%s: Was expecting %s, got %s", name, test.path, rec.path)
+		}
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusOK, `{"data": {"type": "authors", "id": "1"}}`, rec)
+	defer server.Close()
+
+	c := client.New(server.URL)
+	if err := c.Get(context.Background(), "/authors/1", new(Author), nil); err != nil {
+		t.Fatalf("Was not expecting responses to be validated by default, got %v", err)
+	}
+
+	c.Options = []jsonapi.Option{jsonapi.WithChecks(true)}
+	err := c.Get(context.Background(), "/authors/1", new(Author), nil)
+	if _, ok := err.(jsonapi.ValidationErrors); !ok {
+		t.Fatalf("Was expecting ValidationErrors, got %v", err)
+	}
+
+	c.Options = []jsonapi.Option{jsonapi.WithLimits(jsonapi.Limits{MaxBodyBytes: 10})}
+	err = c.Get(context.Background(), "/authors/1", new(Author), nil)
+	if e, ok := err.(*jsonapi.ErrorObject); !ok || e.Code != jsonapi.LimitExceededCode {
+		t.Fatalf("Was expecting the limit to be exceeded, got %v", err)
+	}
+}
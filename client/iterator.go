@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+// ErrNoItem is returned by Iterator.Scan and Iterator.ScanPage when Next or
+// NextPage hasn't advanced to an item or page.
+var ErrNoItem = errors.New("jsonapi client: no current item; call Next or NextPage first")
+
+// Iterator walks a paginated collection, following the "next" link of each
+// page until there is none, it leads to a page already fetched, a limit is
+// hit, or its context is done. Call
+// either Next and Scan to walk it item by item:
+//
+//	it := c.Iterate(ctx, "/blogs", &client.Query{Page: map[string]string{"size": "50"}})
+//	for it.Next() {
+//		blog := new(Blog)
+//		if err := it.Scan(blog); err != nil {
+//			return err
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+//
+// or NextPage and ScanPage to walk it page by page:
+//
+//	for it.NextPage() {
+//		var blogs []*Blog
+//		if err := it.ScanPage(&blogs); err != nil {
+//			return err
+//		}
+//		total := (*it.Meta())["total"]
+//	}
+//
+// An Iterator must not be walked both ways.
+type Iterator struct {
+	// MaxPages stops the walk after this many pages have been fetched. Zero
+	// means no limit.
+	MaxPages int
+
+	// MaxItems stops the walk after this many items have been yielded. The
+	// last page is truncated by ScanPage if needed. Zero means no limit.
+	MaxItems int
+
+	client *Client
+	ctx    context.Context
+	next   string
+	query  *Query
+
+	page  *page
+	index int
+	pages int
+	items int
+	err   error
+
+	// fetched holds the URLs of the pages fetched so far, so that a "next"
+	// link back to one of them ends the walk rather than looping over it.
+	fetched map[string]bool
+}
+
+// page is a fetched page of a collection.
+type page struct {
+	doc  *jsonapi.Document
+	opts []jsonapi.Option
+
+	// models holds the items of the page once decoded by Scan, as a slice
+	// of the scanned model type.
+	models reflect.Value
+
+	// yielded is how many items of the page count towards MaxItems.
+	yielded int
+}
+
+// Iterate returns an Iterator over the collection at url. The first page is
+// fetched with query; the following ones with their "next" link as is.
+func (c *Client) Iterate(ctx context.Context, url string, query *Query) *Iterator {
+	return &Iterator{client: c, ctx: ctx, next: url, query: query, fetched: make(map[string]bool)}
+}
+
+// Next advances to the next item, fetching the next page when the current
+// one is exhausted. It returns false at the end of the walk or on error.
+func (it *Iterator) Next() bool {
+	for {
+		if it.MaxItems > 0 && it.items >= it.MaxItems {
+			return false
+		}
+
+		if it.page != nil && it.index+1 < len(it.page.doc.Data()) {
+			it.index++
+			it.items++
+			return true
+		}
+
+		if !it.fetch() {
+			return false
+		}
+	}
+}
+
+// NextPage advances to the next page. It returns false at the end of the
+// walk or on error.
+func (it *Iterator) NextPage() bool {
+	if it.MaxItems > 0 && it.items >= it.MaxItems {
+		return false
+	}
+
+	if !it.fetch() {
+		return false
+	}
+
+	it.page.yielded = len(it.page.doc.Data())
+	if it.MaxItems > 0 && it.items+it.page.yielded > it.MaxItems {
+		it.page.yielded = it.MaxItems - it.items
+	}
+	it.items += it.page.yielded
+
+	return true
+}
+
+// Scan unmarshals the current item, along with the page's included
+// resources, into model, a struct pointer. The page is decoded once, on its
+// first Scan, so an error in any of its items is returned by that Scan.
+func (it *Iterator) Scan(model interface{}) error {
+	if it.page == nil || it.index < 0 {
+		return ErrNoItem
+	}
+
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return jsonapi.ErrUnexpectedType
+	}
+
+	if !it.page.models.IsValid() || it.page.models.Type().Elem() != v.Type() {
+		models := reflect.New(reflect.SliceOf(v.Type()))
+		if err := it.page.doc.UnmarshalData(models.Interface(), it.page.opts...); err != nil {
+			return err
+		}
+		it.page.models = models.Elem()
+	}
+
+	v.Elem().Set(it.page.models.Index(it.index).Elem())
+
+	return nil
+}
+
+// ScanPage unmarshals the items of the current page into models, a pointer
+// to a slice of struct pointers.
+func (it *Iterator) ScanPage(models interface{}) error {
+	if it.page == nil {
+		return ErrNoItem
+	}
+
+	if err := it.page.doc.UnmarshalData(models, it.page.opts...); err != nil {
+		return err
+	}
+
+	slice := reflect.ValueOf(models).Elem()
+	if slice.Len() > it.page.yielded {
+		slice.Set(slice.Slice(0, it.page.yielded))
+	}
+
+	return nil
+}
+
+// Meta returns the top level meta of the current page, or nil if it has
+// none.
+func (it *Iterator) Meta() *jsonapi.Meta {
+	if it.page == nil {
+		return nil
+	}
+
+	return it.page.doc.Meta
+}
+
+// Links returns the top level links of the current page, or nil if it has
+// none.
+func (it *Iterator) Links() *jsonapi.Links {
+	if it.page == nil {
+		return nil
+	}
+
+	return it.page.doc.Links
+}
+
+// Err returns the error that stopped the walk, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// fetch fetches the next page, if the walk isn't over.
+func (it *Iterator) fetch() bool {
+	if it.err != nil || it.next == "" {
+		return false
+	}
+	if it.MaxPages > 0 && it.pages >= it.MaxPages {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+
+	resp, err := it.client.send(it.ctx, http.MethodGet, it.next, it.query, nil)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	defer resp.Body.Close()
+
+	p := &page{opts: it.client.options(it.ctx)}
+	if p.doc, it.err = jsonapi.NewDecoder(resp.Body, p.opts...).DecodeDocument(); it.err != nil {
+		return false
+	}
+
+	it.page = p
+	it.index = -1
+	it.pages++
+	it.query = nil
+	// A bad next link ends the walk after this page.
+	it.fetched[resp.Request.URL.String()] = true
+	if it.next, it.err = nextLink(resp.Request.URL, p.doc.Links); it.fetched[it.next] {
+		it.next = ""
+	}
+
+	return true
+}
+
+// nextLink returns the "next" link of a page, resolved against the URL the
+// page was fetched from, or "" if there is none.
+func nextLink(base *url.URL, links *jsonapi.Links) (string, error) {
+	if links == nil {
+		return "", nil
+	}
+
+	next, err := links.Link(jsonapi.KeyNextPage)
+	if err != nil || next == nil || next.Href == "" {
+		return "", err
+	}
+
+	ref, err := url.Parse(next.Href)
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,252 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+	"github.com/cheeryfella/jsonapi/client"
+)
+
+// newPagedServer serves /blogs in pages of two, numbered from 1, linking
+// each to the next with alternately a relative string and a link object.
+func newPagedServer(t *testing.T, total int, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RequestURI())
+
+		number, err := strconv.Atoi(r.URL.Query().Get(jsonapi.QueryParamPageNumber))
+		if err != nil {
+			number = 1
+		}
+
+		var data string
+		for id := (number-1)*2 + 1; id <= number*2 && id <= total; id++ {
+			if data != "" {
+				data += ","
+			}
+			data += fmt.Sprintf(`{"type": "blogs", "id": "%d", "attributes": {"title": "Title %d"},
+				"relationships": {"posts": {"data": [{"type": "posts", "id": "p%d"}]}}}`, id, id, id)
+		}
+
+		var included string
+		for id := (number-1)*2 + 1; id <= number*2 && id <= total; id++ {
+			if included != "" {
+				included += ","
+			}
+			included += fmt.Sprintf(`{"type": "posts", "id": "p%d", "attributes": {"title": "Post %d"}}`, id, id)
+		}
+
+		next := "null"
+		if number*2 < total {
+			next = fmt.Sprintf(`"/blogs?page%%5Bnumber%%5D=%d"`, number+1)
+			if number%2 == 0 {
+				next = fmt.Sprintf(`{"href": "http://%s/blogs?page%%5Bnumber%%5D=%d"}`, r.Host, number+1)
+			}
+		}
+
+		w.Header().Set("Content-Type", jsonapi.MediaType)
+		fmt.Fprintf(w, `{"data": [%s], "included": [%s], "links": {"next": %s}, "meta": {"total": %d, "page": %d}}`,
+			data, included, next, total, number)
+	}))
+}
+
+func TestIteratorItems(t *testing.T) {
+	var requests []string
+	server := newPagedServer(t, 7, &requests)
+	defer server.Close()
+
+	query := &client.Query{Sort: []string{"title"}}
+	it := client.New(server.URL).Iterate(context.Background(), "/blogs", query)
+
+	var titles []string
+	for it.Next() {
+		blog := new(Blog)
+		if err := it.Scan(blog); err != nil {
+			t.Fatal(err)
+		}
+		if len(blog.Posts) != 1 || blog.Posts[0].Title != "Post "+blog.ID {
+			t.Fatalf("Was expecting the included post, got %#v", blog.Posts)
+		}
+		titles = append(titles, blog.Title)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(titles) != 7 || titles[6] != "Title 7" {
+		t.Fatalf("Was expecting 7 blogs, got %v", titles)
+	}
+	if len(requests) != 4 {
+		t.Fatalf("Was expecting 4 pages to be fetched, got %v", requests)
+	}
+	if requests[0] != "/blogs?sort=title" || requests[1] != "/blogs?page%5Bnumber%5D=2" {
+		t.Fatalf("Was expecting the query on the first page only, got %v", requests)
+	}
+	if page := (*it.Meta())["page"]; page != float64(4) {
+		t.Fatalf("Was expecting the meta of the last page, got %v", page)
+	}
+}
+
+func TestIteratorPages(t *testing.T) {
+	var requests []string
+	server := newPagedServer(t, 5, &requests)
+	defer server.Close()
+
+	it := client.New(server.URL).Iterate(context.Background(), "/blogs", nil)
+
+	var sizes []int
+	for it.NextPage() {
+		var blogs []*Blog
+		if err := it.ScanPage(&blogs); err != nil {
+			t.Fatal(err)
+		}
+		if total := (*it.Meta())["total"]; total != float64(5) {
+			t.Fatalf("Was expecting a total of 5, got %v", total)
+		}
+		sizes = append(sizes, len(blogs))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(sizes) != "[2 2 1]" {
+		t.Fatalf("Was expecting pages of [2 2 1], got %v", sizes)
+	}
+}
+
+func TestIteratorMaxItems(t *testing.T) {
+	var requests []string
+	server := newPagedServer(t, 10, &requests)
+	defer server.Close()
+
+	it := client.New(server.URL).Iterate(context.Background(), "/blogs", nil)
+	it.MaxItems = 3
+
+	var count int
+	for it.Next() {
+		count++
+	}
+	if count != 3 || len(requests) != 2 {
+		t.Fatalf("Was expecting 3 items from 2 pages, got %d from %d", count, len(requests))
+	}
+
+	requests = nil
+	it = client.New(server.URL).Iterate(context.Background(), "/blogs", nil)
+	it.MaxItems = 3
+
+	var sizes []int
+	for it.NextPage() {
+		var blogs []*Blog
+		if err := it.ScanPage(&blogs); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(blogs))
+	}
+	if fmt.Sprint(sizes) != "[2 1]" {
+		t.Fatalf("Was expecting the last page to be truncated, got %v", sizes)
+	}
+}
+
+func TestIteratorMaxPages(t *testing.T) {
+	var requests []string
+	server := newPagedServer(t, 10, &requests)
+	defer server.Close()
+
+	it := client.New(server.URL).Iterate(context.Background(), "/blogs", nil)
+	it.MaxPages = 2
+
+	var count int
+	for it.Next() {
+		count++
+	}
+	if count != 4 || len(requests) != 2 {
+		t.Fatalf("Was expecting 4 items from 2 pages, got %d from %d", count, len(requests))
+	}
+}
+
+func TestIteratorLoop(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+
+		// Empty pages: the first links to itself, the second back to the
+		// first.
+		next := `"/blogs"`
+		if r.URL.Query().Get("cursor") == "" {
+			next = `"/blogs?cursor=b"`
+		}
+		if r.URL.Query().Get("cursor") == "a" {
+			next = `"/blogs?cursor=a"`
+		}
+
+		w.Header().Set("Content-Type", jsonapi.MediaType)
+		fmt.Fprintf(w, `{"data": [], "links": {"next": %s}}`, next)
+	}))
+	defer server.Close()
+
+	it := client.New(server.URL).Iterate(context.Background(), "/blogs", nil)
+	for it.Next() {
+		t.Fatal("Was expecting no items")
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(requests) != "[/blogs /blogs?cursor=b]" {
+		t.Fatalf("Was expecting each page to be fetched once, got %v", requests)
+	}
+
+	requests = nil
+	it = client.New(server.URL).Iterate(context.Background(), "/blogs?cursor=a", nil)
+	for it.Next() {
+		t.Fatal("Was expecting no items")
+	}
+	if fmt.Sprint(requests) != "[/blogs?cursor=a]" {
+		t.Fatalf("Was expecting a page linking to itself to be fetched once, got %v", requests)
+	}
+}
+
+func TestIteratorContextCanceled(t *testing.T) {
+	var requests []string
+	server := newPagedServer(t, 10, &requests)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := client.New(server.URL).Iterate(ctx, "/blogs", nil)
+
+	if !it.NextPage() {
+		t.Fatal(it.Err())
+	}
+	cancel()
+
+	if it.NextPage() {
+		t.Fatal("Was expecting the walk to stop once the context is canceled")
+	}
+	if it.Err() != context.Canceled {
+		t.Fatalf("Was expecting context.Canceled, got %v", it.Err())
+	}
+	if len(requests) != 1 {
+		t.Fatalf("Was expecting no request after cancelation, got %v", requests)
+	}
+}
+
+func TestIteratorError(t *testing.T) {
+	rec := new(recorded)
+	server := newServer(t, http.StatusBadRequest, `{"errors": [{"status": "400", "title": "Bad page size"}]}`, rec)
+	defer server.Close()
+
+	it := client.New(server.URL).Iterate(context.Background(), "/blogs", nil)
+	if it.Next() {
+		t.Fatal("Was expecting no items")
+	}
+
+	if apiErr, ok := it.Err().(*client.Error); !ok || apiErr.Errors[0].Title != "Bad page size" {
+		t.Fatalf("Was expecting the error document, got %v", it.Err())
+	}
+	if err := it.Scan(new(Blog)); err != client.ErrNoItem {
+		t.Fatalf("Was expecting ErrNoItem, got %v", err)
+	}
+}
@@ -0,0 +1,385 @@
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EncodeFunc converts an attribute's field value into the value written to
+// the "attributes" hash. The result is serialized with encoding/json.
+type EncodeFunc func(value interface{}) (interface{}, error)
+
+// DecodeFunc converts the raw JSON of an attribute, which may be the literal
+// null, into a value of the type the codec was registered for.
+type DecodeFunc func(raw json.RawMessage) (interface{}, error)
+
+type codec struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[reflect.Type]codec{}
+
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	interfaceType       = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// ErrAttributeDecode is returned when a registered codec, json.Unmarshaler or
+// encoding.TextUnmarshaler fails to decode an attribute.
+type ErrAttributeDecode struct {
+	Type reflect.Type
+	Err  error
+}
+
+func (ead ErrAttributeDecode) Error() string {
+	return fmt.Sprintf("jsonapi: can't unmarshal attribute into %s: %v", ead.Type, ead.Err)
+}
+
+// Unwrap returns the error returned by the decoder.
+func (ead ErrAttributeDecode) Unwrap() error {
+	return ead.Err
+}
+
+// RegisterCodec registers the functions used to marshal and unmarshal
+// attributes of type t. Registered codecs take precedence over the built in
+// handling of t, including time.Time and the json.Marshaler,
+// json.Unmarshaler, encoding.TextMarshaler and encoding.TextUnmarshaler
+// interfaces. Either function may be nil to fall back to the default
+// behaviour in that direction.
+//
+// Attributes of type *t use the codec too; a nil pointer is marshaled as
+// null without calling encode.
+func RegisterCodec(t reflect.Type, encode EncodeFunc, decode DecodeFunc) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[t] = codec{encode: encode, decode: decode}
+}
+
+// UnregisterCodec removes the codec registered for t, if any, restoring the
+// built in handling of t.
+func UnregisterCodec(t reflect.Type) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	delete(codecs, t)
+}
+
+func lookupCodec(t reflect.Type) (codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[t]
+	return c, ok
+}
+
+// hasEncoder reports whether a codec with an encode function is registered
+// for t, or for the type t points to.
+func hasEncoder(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	c, ok := lookupCodec(t)
+	return ok && c.encode != nil
+}
+
+// usesRawJSON reports whether attributes of type t are decoded from their raw
+// JSON, i.e. by a registered codec or a json.Unmarshaler.
+func usesRawJSON(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if c, ok := lookupCodec(t); ok && c.decode != nil {
+		return true
+	}
+
+	return !t.ConvertibleTo(timeType) && reflect.PtrTo(t).Implements(jsonUnmarshalerType)
+}
+
+// encodeAttribute returns the value written to the "attributes" hash for
+// fieldValue, consulting registered codecs and the json.Marshaler and
+// encoding.TextMarshaler interfaces so that their errors are surfaced. args
+// are the attr tag arguments, which select the format of times in slices.
+func encodeAttribute(fieldValue reflect.Value, args []string) (interface{}, error) {
+	t := fieldValue.Type()
+
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return fieldValue.Interface(), nil
+		}
+		if c, ok := lookupCodec(t.Elem()); ok && c.encode != nil {
+			return c.encode(fieldValue.Elem().Interface())
+		}
+	}
+
+	if c, ok := lookupCodec(t); ok && c.encode != nil {
+		return c.encode(fieldValue.Interface())
+	}
+
+	if needsEncoding(t) {
+		return encodeComposite(fieldValue, args)
+	}
+
+	if m, ok := implementation(fieldValue, jsonMarshalerType); ok {
+		b, err := m.(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(b), nil
+	}
+
+	if m, ok := implementation(fieldValue, textMarshalerType); ok {
+		text, err := m.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	if strAttr, ok := fieldValue.Interface().(string); ok {
+		return strAttr, nil
+	}
+
+	return fieldValue.Interface(), nil
+}
+
+// needsEncoding reports whether values of type t must be converted before
+// being handed to encoding/json: times, which follow the attr tag's format,
+// types with a registered codec, and structs with jsonapi attr tags, as well
+// as pointers, slices, arrays, maps and interfaces that may hold them.
+func needsEncoding(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		return needsEncoding(t.Elem())
+	}
+
+	if t.ConvertibleTo(timeType) {
+		return true
+	}
+
+	if c, ok := lookupCodec(t); ok && c.encode != nil {
+		return true
+	}
+
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return needsEncoding(t.Elem())
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		return hasAttributeTags(t)
+	}
+
+	return false
+}
+
+// hasAttributeTags reports whether the struct type t has a field with a
+// jsonapi attr tag. Structs without one are encoded using their json tags.
+func hasAttributeTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get(annotationJSONAPI)
+		if strings.HasPrefix(tag, annotationAttribute+annotationSeperator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodeComposite encodes a value for which needsEncoding is true, encoding
+// each element with encodeAttribute. args are the attr tag arguments of the
+// field holding the value.
+func encodeComposite(v reflect.Value, args []string) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeAttribute(v.Elem(), args)
+	case reflect.Struct:
+		if v.Type().ConvertibleTo(timeType) {
+			return formatTime(v.Convert(timeType).Interface().(time.Time), args)
+		}
+		return encodeStruct(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		fallthrough
+	case reflect.Array:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			value, err := encodeAttribute(v.Index(i), args)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		values := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), interfaceType), v.Len())
+		for _, key := range v.MapKeys() {
+			value, err := encodeAttribute(v.MapIndex(key), args)
+			if err != nil {
+				return nil, err
+			}
+			values.SetMapIndex(key, reflect.ValueOf(&value).Elem())
+		}
+		return values.Interface(), nil
+	}
+
+	return v.Interface(), nil
+}
+
+// encodeStruct encodes a nested struct attribute into a hash keyed by its
+// jsonapi attr tags, following the same rules as the resource's own
+// attributes.
+func encodeStruct(v reflect.Value) (map[string]interface{}, error) {
+	attributes := make(map[string]interface{})
+
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		tag := structField.Tag.Get(annotationJSONAPI)
+		if tag == "" || structField.PkgPath != "" {
+			continue
+		}
+
+		args := strings.Split(tag, annotationSeperator)
+		if len(args) < 2 || args[0] != annotationAttribute {
+			continue
+		}
+
+		var omitEmpty bool
+		for _, arg := range args[2:] {
+			if arg == annotationOmitEmpty {
+				omitEmpty = true
+			}
+		}
+
+		fieldValue := v.Field(i)
+		if !hasEncoder(fieldValue.Type()) && fieldValue.Type() == timeType &&
+			fieldValue.Interface().(time.Time).IsZero() {
+			continue
+		}
+		if omitEmpty && reflect.DeepEqual(fieldValue.Interface(), reflect.Zero(fieldValue.Type()).Interface()) {
+			continue
+		}
+
+		value, err := encodeAttribute(fieldValue, args)
+		if err != nil {
+			return nil, err
+		}
+		attributes[args[1]] = value
+	}
+
+	return attributes, nil
+}
+
+// implementation returns fieldValue, or its address, as iface if either
+// implements it.
+func implementation(fieldValue reflect.Value, iface reflect.Type) (interface{}, bool) {
+	if fieldValue.Type().Implements(iface) {
+		return fieldValue.Interface(), true
+	}
+
+	if fieldValue.CanAddr() && reflect.PtrTo(fieldValue.Type()).Implements(iface) {
+		return fieldValue.Addr().Interface(), true
+	}
+
+	return nil, false
+}
+
+// decodeAttribute decodes attribute into a value of fieldType using a
+// registered codec, json.Unmarshaler or encoding.TextUnmarshaler. ok is false
+// when fieldType has no custom decoding.
+func decodeAttribute(attribute interface{}, fieldType reflect.Type) (value reflect.Value, ok bool, err error) {
+	if c, found := lookupCodec(fieldType); found && c.decode != nil {
+		raw, err := rawAttribute(attribute)
+		if err != nil {
+			return reflect.Value{}, true, err
+		}
+
+		decoded, err := c.decode(raw)
+		if err != nil {
+			return reflect.Value{}, true, ErrAttributeDecode{fieldType, err}
+		}
+
+		v := reflect.ValueOf(decoded)
+		if !v.IsValid() {
+			return reflect.Zero(fieldType), true, nil
+		}
+		if v.Type() != fieldType {
+			if !v.Type().ConvertibleTo(fieldType) {
+				return reflect.Value{}, true, ErrInvalidType
+			}
+			v = v.Convert(fieldType)
+		}
+
+		return v, true, nil
+	}
+
+	if fieldType.ConvertibleTo(timeType) {
+		return reflect.Value{}, false, nil
+	}
+
+	model := reflect.New(fieldType)
+
+	if unmarshaler, isUnmarshaler := model.Interface().(json.Unmarshaler); isUnmarshaler {
+		raw, err := rawAttribute(attribute)
+		if err != nil {
+			return reflect.Value{}, true, err
+		}
+
+		if err := unmarshaler.UnmarshalJSON(raw); err != nil {
+			return reflect.Value{}, true, ErrAttributeDecode{fieldType, err}
+		}
+
+		return model, true, nil
+	}
+
+	if unmarshaler, isUnmarshaler := model.Interface().(encoding.TextUnmarshaler); isUnmarshaler {
+		text, isString := attribute.(string)
+		if !isString {
+			return reflect.Value{}, true, ErrAttributeDecode{fieldType, fmt.Errorf(
+				"can't unmarshal value of type %T as text", attribute)}
+		}
+
+		if err := unmarshaler.UnmarshalText([]byte(text)); err != nil {
+			return reflect.Value{}, true, ErrAttributeDecode{fieldType, err}
+		}
+
+		return model, true, nil
+	}
+
+	return reflect.Value{}, false, nil
+}
+
+// rawAttribute returns the raw JSON of an attribute, re-encoding it if the
+// raw form was not kept.
+func rawAttribute(attribute interface{}) (json.RawMessage, error) {
+	if raw, ok := attribute.(json.RawMessage); ok {
+		return raw, nil
+	}
+
+	return json.Marshal(attribute)
+}
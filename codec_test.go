@@ -0,0 +1,190 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+type Money struct {
+	Cents int64
+}
+
+type Invoice struct {
+	ID       string           `jsonapi:"primary,invoices"`
+	Total    Money            `jsonapi:"attr,total"`
+	Discount *Money           `jsonapi:"attr,discount"`
+	Host     net.IP           `jsonapi:"attr,host"`
+	Status   Status           `jsonapi:"attr,status"`
+	Amount   *jsonapi.JSONInt `jsonapi:"attr,amount"`
+}
+
+type Status string
+
+var errBadStatus = errors.New("unknown status")
+
+func (s Status) MarshalJSON() ([]byte, error) {
+	if s == "" {
+		return nil, errBadStatus
+	}
+	return json.Marshal(strings.ToUpper(string(s)))
+}
+
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	if str != "PAID" && str != "DUE" {
+		return errBadStatus
+	}
+	*s = Status(strings.ToLower(str))
+	return nil
+}
+
+// registerMoney registers the codec of Money, which writes amounts as
+// strings, and returns the func that unregisters it.
+func registerMoney() func() {
+	moneyType := reflect.TypeOf(Money{})
+	jsonapi.RegisterCodec(moneyType,
+		func(value interface{}) (interface{}, error) {
+			m := value.(Money)
+			return fmt.Sprintf("%d.%02d", m.Cents/100, m.Cents%100), nil
+		},
+		func(raw json.RawMessage) (interface{}, error) {
+			var str string
+			if err := json.Unmarshal(raw, &str); err != nil {
+				return nil, err
+			}
+			var whole, fraction int64
+			if _, err := fmt.Sscanf(str, "%d.%d", &whole, &fraction); err != nil {
+				return nil, err
+			}
+			return Money{Cents: whole*100 + fraction}, nil
+		},
+	)
+
+	return func() {
+		jsonapi.UnregisterCodec(moneyType)
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	defer registerMoney()()
+
+	in := &Invoice{
+		ID:       "1",
+		Total:    Money{Cents: 1234},
+		Discount: &Money{Cents: 50},
+		Host:     net.ParseIP("10.0.0.1"),
+		Status:   "paid",
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, in); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+		t.Fatal(err)
+	}
+	attributes := jsonData["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"total":    "12.34",
+		"discount": "0.50",
+		"host":     "10.0.0.1",
+		"status":   "PAID",
+		"amount":   nil,
+	}
+	if !reflect.DeepEqual(expected, attributes) {
+		t.Fatalf("Was expecting %v, got %v", expected, attributes)
+	}
+
+	decoded := new(Invoice)
+	if err := jsonapi.UnmarshalPayload(out, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if e, a := in.Total, decoded.Total; e != a {
+		t.Fatalf("Was expecting total %v, got %v", e, a)
+	}
+	if decoded.Discount == nil || *decoded.Discount != *in.Discount {
+		t.Fatalf("Was expecting discount %v, got %v", in.Discount, decoded.Discount)
+	}
+	if !in.Host.Equal(decoded.Host) {
+		t.Fatalf("Was expecting host %v, got %v", in.Host, decoded.Host)
+	}
+	if e, a := in.Status, decoded.Status; e != a {
+		t.Fatalf("Was expecting status %v, got %v", e, a)
+	}
+	if decoded.Amount == nil || !decoded.Amount.Null {
+		t.Fatalf("Was expecting amount to be decoded as null, got %v", decoded.Amount)
+	}
+}
+
+func TestCodecMarshalErrorSurfaced(t *testing.T) {
+	err := jsonapi.MarshalPayload(bytes.NewBuffer(nil), &Invoice{ID: "1"})
+	if err != errBadStatus {
+		t.Fatalf("Was expecting %v, got %v", errBadStatus, err)
+	}
+}
+
+func TestCodecUnmarshalErrorsSurfaced(t *testing.T) {
+	defer registerMoney()()
+
+	var tests = map[string]struct {
+		Attributes string
+		Err        error
+	}{
+		"Unmarshaler": {
+			Attributes: `{"status": "LOST"}`,
+			Err:        errBadStatus,
+		},
+		"TextUnmarshaler": {
+			Attributes: `{"host": "not an ip"}`,
+		},
+		"Codec": {
+			Attributes: `{"total": 12}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			in := fmt.Sprintf(`{"data": {"type": "invoices", "id": "1", "attributes": %s}}`, test.Attributes)
+
+			err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Invoice))
+			decodeErr, ok := err.(jsonapi.ErrAttributeDecode)
+			if !ok {
+				t.Fatalf("Was expecting ErrAttributeDecode, got %T: %v", err, err)
+			}
+			if test.Err != nil && decodeErr.Err != test.Err {
+				t.Fatalf("Was expecting %v, got %v", test.Err, err)
+			}
+		})
+	}
+}
+
+func TestUnregisterCodec(t *testing.T) {
+	unregister := registerMoney()
+	in := `{"data": {"type": "invoices", "id": "1", "attributes": {"total": "1.50"}}}`
+
+	invoice := new(Invoice)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), invoice); err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Total.Cents != 150 {
+		t.Fatalf("Was expecting 150 cents, got %d", invoice.Total.Cents)
+	}
+
+	unregister()
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Invoice)); err == nil {
+		t.Fatal("Was expecting the string to be rejected once the codec is unregistered")
+	}
+}
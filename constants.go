@@ -10,7 +10,27 @@ const (
 	annotationISO8601   = "iso8601"
 	annotationSeperator = ","
 
+	// Time format annotations for attr fields
+	annotationRFC3339     = "rfc3339"
+	annotationRFC3339Nano = "rfc3339nano"
+	annotationUnixMilli   = "unixmilli"
+	annotationDate        = "date"
+	annotationLayout      = "layout"
+
+	// Validation annotations for attr fields
+	annotationRequired       = "required"
+	annotationMin            = "min"
+	annotationMax            = "max"
+	annotationMaxLen         = "maxlen"
+	annotationEnum           = "enum"
+	annotationFormat         = "format"
+	annotationValueSeperator = "="
+	annotationEnumSeperator  = "|"
+
+	formatEmail = "email"
+
 	iso8601TimeFormat = "2006-01-02T15:04:05Z"
+	dateFormat        = "2006-01-02"
 
 	// MediaType is the identifier for the JSON API media type
 	//
@@ -0,0 +1,279 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownMember is returned by a strict Decoder when a resource has an
+// attribute or relationship that its model doesn't declare. Pointer is the
+// JSON pointer of the member, e.g. "/data/attributes/nickname".
+type ErrUnknownMember struct {
+	Pointer string
+}
+
+func (eum ErrUnknownMember) Error() string {
+	return fmt.Sprintf("jsonapi: unknown member %s", eum.Pointer)
+}
+
+// A Decoder reads JSON API documents from an input stream.
+//
+//	dec := jsonapi.NewDecoder(r.Body,
+//		jsonapi.WithContext(r.Context()),
+//		jsonapi.WithStrict(true),
+//	)
+//	blog := new(Blog)
+//	if err := dec.Decode(blog); err != nil {
+//		http.Error(w, err.Error(), http.StatusBadRequest)
+//	}
+type Decoder struct {
+	r    io.Reader
+	opts *options
+}
+
+// NewDecoder returns a Decoder that reads from r, configured by opts.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	return &Decoder{r: r, opts: newOptions(opts)}
+}
+
+// Decode reads a document into model. A struct pointer is populated from a
+// document with a single resource, as UnmarshalPayload does; a pointer to a
+// slice of struct pointers is set to the resources of a document with many,
+// as UnmarshalManyPayload does.
+func (d *Decoder) Decode(model interface{}) error {
+	return decodeModels(model, d.decodeOne, d.decodeMany)
+}
+
+// decodeModels populates model, a struct pointer, with one, or sets model, a
+// pointer to a slice of struct pointers, to the models created by many.
+func decodeModels(model interface{}, one func(model interface{}) error, many func(t reflect.Type) ([]interface{}, error)) error {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrUnexpectedType
+	}
+
+	switch elem := v.Elem(); elem.Kind() {
+	case reflect.Struct:
+		return one(model)
+	case reflect.Slice:
+		t := elem.Type().Elem()
+		if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return ErrExpectedSlice
+		}
+
+		models, err := many(t)
+		if err != nil {
+			return err
+		}
+
+		slice := reflect.MakeSlice(elem.Type(), len(models), len(models))
+		for i, m := range models {
+			slice.Index(i).Set(reflect.ValueOf(m))
+		}
+		elem.Set(slice)
+
+		return nil
+	default:
+		return ErrUnexpectedType
+	}
+}
+
+// DecodeErrors reads an errors document and returns its error objects, as
+// UnmarshalErrors does.
+func (d *Decoder) DecodeErrors() ([]*ErrorObject, error) {
+	w := newWalk(d.opts)
+
+	payload := new(struct {
+		Errors *[]*ErrorObject `json:"errors"`
+	})
+	err := w.trace(SpanDecodeJSON, nil, func() error {
+		return json.NewDecoder(d.reader()).Decode(payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Errors == nil {
+		return nil, ErrExpectedErrors
+	}
+
+	return *payload.Errors, nil
+}
+
+// reader returns the Decoder's input, checked against its Limits as it is
+// read and counting the bytes read when the call is instrumented.
+func (d *Decoder) reader() io.Reader {
+	r := scanLimits(limitReader(d.r, d.opts.limits.MaxBodyBytes), d.opts.limits)
+	if d.opts.stats != nil {
+		return countingReader{r: r, n: &d.opts.stats.BytesRead}
+	}
+
+	return r
+}
+
+func (d *Decoder) decodeOne(model interface{}) error {
+	w := newWalk(d.opts)
+
+	// Explicit nulls reach codecs and json.Unmarshalers through the raw JSON
+	// the resource keeps of its attributes.
+	payload := new(OnePayload)
+	err := w.trace(SpanDecodeJSON, nil, func() error {
+		return json.NewDecoder(d.reader()).Decode(payload)
+	})
+	if err != nil {
+		return err
+	}
+
+	return unmarshalOne(w, payload.Data, payload.Included, make(map[string]interface{}), model)
+}
+
+func (d *Decoder) decodeMany(t reflect.Type) ([]interface{}, error) {
+	w := newWalk(d.opts)
+
+	payload := new(ManyPayload)
+	err := w.trace(SpanDecodeJSON, nil, func() error {
+		return json.NewDecoder(d.reader()).Decode(payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalMany(w, payload.Data, payload.Included, t)
+}
+
+// unmarshalOne populates model from a single primary resource and the
+// included resources of its document.
+func unmarshalOne(w *walk, data *ResourceObj, included []*ResourceObj, nulls map[string]interface{}, model interface{}) error {
+	if data == nil {
+		return fmt.Errorf("data is not a jsonapi representation of '%v'", reflect.TypeOf(model))
+	}
+
+	if err := checkLimits(w.opts.limits, []*ResourceObj{data}, true, included); err != nil {
+		return err
+	}
+
+	w.stats.Included = len(included)
+	w.stats.Resources = 1
+	data.pointer = "/data"
+
+	var includedMap *map[string]*ResourceObj
+	if included != nil {
+		resolved := resolveIncluded(w, included)
+		includedMap = &resolved
+	}
+
+	w.register(data, reflect.ValueOf(model))
+
+	return w.trace(SpanVisitModelGraph, map[string]interface{}{"resources": w.stats.Resources}, func() error {
+		return unmarshalNode(w, data, nulls, reflect.ValueOf(model), includedMap, "/data")
+	})
+}
+
+// unmarshalMany creates models of type t from the primary resources and the
+// included resources of a document.
+func unmarshalMany(w *walk, data []*ResourceObj, included []*ResourceObj, t reflect.Type) ([]interface{}, error) {
+	if err := checkLimits(w.opts.limits, data, false, included); err != nil {
+		return nil, err
+	}
+
+	models := []interface{}{}                   // will be populated from the "data"
+	includedMap := resolveIncluded(w, included) // will be populate from the "included"
+
+	w.stats.Resources = len(data)
+	w.stats.Included = len(included)
+
+	// Register all the primary models first, so relationships between them
+	// share them whatever their order.
+	primary := make([]reflect.Value, len(data))
+	for i, node := range data {
+		primary[i] = reflect.New(t.Elem())
+		w.register(node, primary[i])
+	}
+
+	err := w.trace(SpanVisitModelGraph, map[string]interface{}{"resources": w.stats.Resources}, func() error {
+		for i, node := range data {
+			if node == nil {
+				return fmt.Errorf("data is not a jsonapi representation of '%v'", t)
+			}
+
+			model := primary[i]
+			nulls := make(map[string]interface{})
+			pointer := fmt.Sprintf("/data/%d", i)
+			node.pointer = pointer
+
+			err := unmarshalNode(w, node, nulls, model, &includedMap, pointer)
+			if err != nil {
+				return err
+			}
+			models = append(models, model.Interface())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+// resolveIncluded indexes the included resources by type and ID.
+func resolveIncluded(w *walk, included []*ResourceObj) map[string]*ResourceObj {
+	includedMap := make(map[string]*ResourceObj, len(included))
+
+	w.trace(SpanResolveIncluded, map[string]interface{}{"included": len(included)}, func() error {
+		for i, node := range included {
+			if node == nil {
+				continue
+			}
+
+			key := fmt.Sprintf("%s,%s", node.Type, node.ID)
+			node.pointer = fmt.Sprintf("/included/%d", i)
+			includedMap[key] = node
+		}
+
+		return nil
+	})
+
+	return includedMap
+}
+
+// checkUnknownMembers returns ErrUnknownMember for the first attribute or
+// relationship of data that modelType has no field for.
+func checkUnknownMembers(data *ResourceObj, modelType reflect.Type, pointer string) error {
+	known := map[string]map[string]bool{
+		annotationAttribute: {},
+		annotationRelation:  {},
+	}
+
+	for i := 0; i < modelType.NumField(); i++ {
+		args := strings.Split(modelType.Field(i).Tag.Get(annotationJSONAPI), annotationSeperator)
+		if len(args) < 2 || known[args[0]] == nil {
+			continue
+		}
+		known[args[0]][args[1]] = true
+	}
+
+	var unknown []string
+	for name := range data.Attributes {
+		if !known[annotationAttribute][name] {
+			unknown = append(unknown, fmt.Sprintf("%s/attributes/%s", pointer, name))
+		}
+	}
+	for name := range data.Relationships {
+		if !known[annotationRelation][name] {
+			unknown = append(unknown, fmt.Sprintf("%s/relationships/%s", pointer, name))
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	return ErrUnknownMember{Pointer: unknown[0]}
+}
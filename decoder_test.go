@@ -0,0 +1,97 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestDecoder_decodeMany(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, []*Blog{testBlog(), testBlog()}); err != nil {
+		t.Fatal(err)
+	}
+
+	var blogs []*Blog
+	if err := jsonapi.NewDecoder(out).Decode(&blogs); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := 2, len(blogs); e != a {
+		t.Fatalf("Was expecting %d blogs, got %d", e, a)
+	}
+	if e, a := "Foo", blogs[1].CurrentPost.Title; e != a {
+		t.Fatalf("Was expecting current post title `%s`, got `%s`", e, a)
+	}
+}
+
+func TestDecoder_unexpectedType(t *testing.T) {
+	var blog Blog
+	if err := jsonapi.NewDecoder(strings.NewReader(`{}`)).Decode(blog); err != jsonapi.ErrUnexpectedType {
+		t.Fatalf("Was expecting %v, got %v", jsonapi.ErrUnexpectedType, err)
+	}
+}
+
+func TestDecoder_strict(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "comments",
+			"id": "1",
+			"attributes": {"body": "foo", "rating": 5}
+		}
+	}`
+
+	if err := jsonapi.NewDecoder(strings.NewReader(in)).Decode(new(Comment)); err != nil {
+		t.Fatalf("Was not expecting an error without strict, got %v", err)
+	}
+
+	err := jsonapi.NewDecoder(strings.NewReader(in), jsonapi.WithStrict(true)).Decode(new(Comment))
+	unknown, ok := err.(jsonapi.ErrUnknownMember)
+	if !ok {
+		t.Fatalf("Was expecting ErrUnknownMember, got %v", err)
+	}
+	if e, a := "/data/attributes/rating", unknown.Pointer; e != a {
+		t.Fatalf("Was expecting pointer `%s`, got `%s`", e, a)
+	}
+}
+
+func TestDecoder_maxDepth(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, testBlog()); err != nil {
+		t.Fatal(err)
+	}
+
+	blog := new(Blog)
+	if err := jsonapi.NewDecoder(out, jsonapi.WithMaxDepth(1)).Decode(blog); err != nil {
+		t.Fatal(err)
+	}
+
+	post := blog.Posts[0]
+	if e, a := "Foo", post.Title; e != a {
+		t.Fatalf("Was expecting post title `%s`, got `%s`", e, a)
+	}
+	if e, a := 1, post.Comments[0].ID; e != a {
+		t.Fatalf("Was expecting comment id %d, got %d", e, a)
+	}
+	if post.Comments[0].Body != "" {
+		t.Fatalf("Was expecting comments beyond the max depth to be unresolved, got `%s`",
+			post.Comments[0].Body)
+	}
+}
+
+func TestDecoder_context(t *testing.T) {
+	in := `{"data": {"type": "writers", "id": "1", "attributes": {"email": "a@example.com"}}}`
+	ctx := context.WithValue(context.Background(), noteKey{}, "from context")
+
+	writer := new(Writer)
+	if err := jsonapi.NewDecoder(strings.NewReader(in), jsonapi.WithContext(ctx)).Decode(writer); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "from context", writer.Note; e != a {
+		t.Fatalf("Was expecting the hook to see `%s`, got `%s`", e, a)
+	}
+}
@@ -55,6 +55,22 @@ The following extra arguments are also supported:
 
 "omitempty": excludes the fields value from the "attribute" hash.
 "iso8601": uses the ISO8601 timestamp format when serialising or deserialising the time.Time value.
+"rfc3339", "rfc3339nano": use the RFC 3339 format, keeping the time's offset.
+"unixmilli": uses a number of milliseconds since the Unix epoch.
+"date": uses a calendar date, e.g. "2016-08-17".
+"layout=<layout>": uses a time.Parse layout, which must not contain commas.
+
+Times without a format option are unix seconds; fractional seconds are accepted when unmarshalling.
+The format options also apply to *time.Time, []time.Time and []*time.Time fields.
+
+The following validation arguments are checked after unmarshalling, failures are returned as
+ValidationErrors with a 422 status and a source pointer at the attribute:
+
+"required": the attribute must be present and not null.
+"min=<n>", "max=<n>": bounds for numbers, or for the length of strings, slices and maps.
+"maxlen=<n>": the maximum length of a string, slice or map.
+"enum=<a>|<b>": the value must be one of the listed values.
+"format=email": the value must be an email address.
 
 Value, relation: "relation,<key name in relationships hash>"
 
@@ -0,0 +1,313 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+)
+
+var (
+	// ErrNoData is returned by Document.UnmarshalData when the document has no
+	// primary data, e.g. an errors document.
+	ErrNoData = errors.New("document has no primary data")
+	// ErrDataMismatch is returned by Document.UnmarshalData when the model
+	// doesn't fit the primary data: a single resource needs a struct pointer,
+	// and an array of resources a pointer to a slice of struct pointers.
+	ErrDataMismatch = errors.New("document primary data doesn't match the model")
+)
+
+// DataKind tells the shape of the primary data of a Document.
+type DataKind int
+
+const (
+	// NoData means the document has no "data" member.
+	NoData DataKind = iota
+	// NullData means "data" is null, e.g. an empty to-one relationship.
+	NullData
+	// OneData means "data" is a single resource object.
+	OneData
+	// ManyData means "data" is an array of resource objects.
+	ManyData
+)
+
+// TopLevel holds the top level links and meta of a document, as returned
+// alongside its models by UnmarshalPayloadWithTopLevel and
+// UnmarshalManyPayloadWithTopLevel.
+type TopLevel struct {
+	// Links holds the document's links by name, e.g. KeyNextPage. Null
+	// links are left out.
+	Links map[string]*Link
+	Meta  Meta
+}
+
+// Link returns the document's link named name, or nil if it has none.
+func (tl *TopLevel) Link(name string) *Link {
+	return tl.Links[name]
+}
+
+// JSONAPIObject is the top level "jsonapi" member of a document, describing
+// the server's implementation.
+type JSONAPIObject struct {
+	Version string   `json:"version,omitempty"`
+	Ext     []string `json:"ext,omitempty"`
+	Profile []string `json:"profile,omitempty"`
+	Meta    *Meta    `json:"meta,omitempty"`
+}
+
+// Document is any top level JSON API document: data, errors or meta only.
+// It is decoded once, and its primary data can then be unmarshalled into
+// models with UnmarshalData.
+//
+//	doc, err := jsonapi.UnmarshalDocument(resp.Body)
+//	if err != nil {
+//		return err
+//	}
+//	if doc.Kind() == jsonapi.ErrorsDocument {
+//		return doc.Errors[0]
+//	}
+//	var blogs []*Blog
+//	err = doc.UnmarshalData(&blogs)
+type Document struct {
+	Included []*ResourceObj `json:"included,omitempty"`
+	Links    *Links         `json:"links,omitempty"`
+	Meta     *Meta          `json:"meta,omitempty"`
+	Errors   []*ErrorObject `json:"errors,omitempty"`
+	JSONAPI  *JSONAPIObject `json:"jsonapi,omitempty"`
+
+	dataKind DataKind
+	data     []*ResourceObj
+}
+
+// UnmarshalDocument reads any top level document.
+func UnmarshalDocument(in io.Reader) (*Document, error) {
+	return NewDecoder(in).DecodeDocument()
+}
+
+// DecodeDocument reads any top level document, as UnmarshalDocument does.
+func (d *Decoder) DecodeDocument() (*Document, error) {
+	return d.decodeDocument(newWalk(d.opts))
+}
+
+// DecodeWithTopLevel reads a document into model, as Decode does, and returns
+// its top level links and meta.
+func (d *Decoder) DecodeWithTopLevel(model interface{}) (*TopLevel, error) {
+	w := newWalk(d.opts)
+
+	doc, err := d.decodeDocument(w)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.unmarshalData(w, model, false); err != nil {
+		return nil, err
+	}
+
+	return doc.TopLevel()
+}
+
+// decodeManyWithTopLevel reads a document into models of type t and returns
+// its top level links and meta.
+func (d *Decoder) decodeManyWithTopLevel(t reflect.Type) ([]interface{}, *TopLevel, error) {
+	slice := reflect.New(reflect.SliceOf(t))
+	top, err := d.DecodeWithTopLevel(slice.Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	models := make([]interface{}, slice.Elem().Len())
+	for i := range models {
+		models[i] = slice.Elem().Index(i).Interface()
+	}
+
+	return models, top, nil
+}
+
+func (d *Decoder) decodeDocument(w *walk) (*Document, error) {
+	doc := new(Document)
+	err := w.trace(SpanDecodeJSON, nil, func() error {
+		return json.NewDecoder(d.reader()).Decode(doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Kind returns whether the document holds data, errors or meta only.
+func (d *Document) Kind() DocumentKind {
+	switch {
+	case d.Errors != nil:
+		return ErrorsDocument
+	case d.dataKind != NoData:
+		return DataDocument
+	case d.Meta != nil:
+		return MetaDocument
+	}
+
+	return 0
+}
+
+// DataKind returns the shape of the document's primary data.
+func (d *Document) DataKind() DataKind {
+	return d.dataKind
+}
+
+// Data returns the resources of the document's primary data: none for null
+// or absent data, and one for a single resource.
+func (d *Document) Data() []*ResourceObj {
+	return d.data
+}
+
+// UnmarshalData populates model, a struct pointer, from a single primary
+// resource, or sets model, a pointer to a slice of struct pointers, to the
+// models of an array of primary resources. Null data leaves a struct
+// untouched and sets a slice to an empty one.
+func (d *Document) UnmarshalData(model interface{}, opts ...Option) error {
+	return d.unmarshalData(newWalk(newOptions(opts)), model, true)
+}
+
+// unmarshalData populates model from the primary data. Unless nullable, null
+// data is rejected for a struct, as UnmarshalPayload does.
+func (d *Document) unmarshalData(w *walk, model interface{}, nullable bool) error {
+	if d.dataKind == NoData {
+		return ErrNoData
+	}
+
+	one := func(model interface{}) error {
+		switch d.dataKind {
+		case NullData:
+			if nullable {
+				return nil
+			}
+			return unmarshalOne(w, nil, d.Included, nil, model)
+		case OneData:
+			return unmarshalOne(w, d.data[0], d.Included, make(map[string]interface{}), model)
+		}
+		return ErrDataMismatch
+	}
+
+	many := func(t reflect.Type) ([]interface{}, error) {
+		if d.dataKind == OneData {
+			return nil, ErrDataMismatch
+		}
+		return unmarshalMany(w, d.data, d.Included, t)
+	}
+
+	return decodeModels(model, one, many)
+}
+
+// TopLevel returns the document's top level links, as Link objects, and
+// meta.
+func (d *Document) TopLevel() (*TopLevel, error) {
+	tl := &TopLevel{Links: map[string]*Link{}, Meta: Meta{}}
+
+	if d.Links != nil {
+		for name := range *d.Links {
+			link, err := d.Links.Link(name)
+			if err != nil {
+				return nil, err
+			}
+			if link != nil {
+				tl.Links[name] = link
+			}
+		}
+	}
+
+	if d.Meta != nil {
+		tl.Meta = *d.Meta
+	}
+
+	return tl, nil
+}
+
+// document is the JSON representation of a Document, besides its data.
+type document struct {
+	Data     json.RawMessage `json:"data,omitempty"`
+	Included []*ResourceObj  `json:"included,omitempty"`
+	Links    *Links          `json:"links,omitempty"`
+	Meta     *Meta           `json:"meta,omitempty"`
+	Errors   []*ErrorObject  `json:"errors,omitempty"`
+	JSONAPI  *JSONAPIObject  `json:"jsonapi,omitempty"`
+}
+
+// UnmarshalJSON decodes a top level document, rejecting those that have
+// neither data, errors nor meta, or both data and errors.
+func (d *Document) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	if _, err := documentKind(members); err != nil {
+		return err
+	}
+
+	aux := new(document)
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	*d = Document{
+		Included: aux.Included,
+		Links:    aux.Links,
+		Meta:     aux.Meta,
+		Errors:   aux.Errors,
+		JSONAPI:  aux.JSONAPI,
+	}
+
+	raw, ok := members["data"]
+	if !ok {
+		return nil
+	}
+
+	switch raw = bytes.TrimSpace(raw); {
+	case bytes.Equal(raw, []byte("null")):
+		d.dataKind = NullData
+	case len(raw) > 0 && raw[0] == '[':
+		d.dataKind = ManyData
+		return json.Unmarshal(raw, &d.data)
+	default:
+		d.dataKind = OneData
+		one := new(ResourceObj)
+		if err := json.Unmarshal(raw, one); err != nil {
+			return err
+		}
+		d.data = []*ResourceObj{one}
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the document, writing its primary data in the shape
+// it was decoded with.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	aux := &document{
+		Included: d.Included,
+		Links:    d.Links,
+		Meta:     d.Meta,
+		Errors:   d.Errors,
+		JSONAPI:  d.JSONAPI,
+	}
+
+	var err error
+	switch d.dataKind {
+	case NullData:
+		aux.Data = json.RawMessage("null")
+	case OneData:
+		aux.Data, err = json.Marshal(d.data[0])
+	case ManyData:
+		data := d.data
+		if data == nil {
+			data = []*ResourceObj{}
+		}
+		aux.Data, err = json.Marshal(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(aux)
+}
@@ -0,0 +1,161 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestDocument_one(t *testing.T) {
+	doc, err := jsonapi.UnmarshalDocument(strings.NewReader(`{
+		"jsonapi": {"version": "1.1", "meta": {"server": "test"}},
+		"data": {
+			"type": "blogs",
+			"id": "5",
+			"attributes": {"title": "Blog"},
+			"relationships": {"current_post": {"data": {"type": "posts", "id": "2"}}}
+		},
+		"included": [{"type": "posts", "id": "2", "attributes": {"title": "Post"}}],
+		"links": {"self": "/blogs/5"},
+		"meta": {"views": 10}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Kind() != jsonapi.DataDocument || doc.DataKind() != jsonapi.OneData {
+		t.Fatalf("Was expecting a single resource, got %v %v", doc.Kind(), doc.DataKind())
+	}
+	if doc.JSONAPI.Version != "1.1" || (*doc.JSONAPI.Meta)["server"] != "test" {
+		t.Fatalf("Was expecting the jsonapi member, got %#v", doc.JSONAPI)
+	}
+	if len(doc.Included) != 1 || (*doc.Links)["self"] != "/blogs/5" || (*doc.Meta)["views"] != float64(10) {
+		t.Fatal("Was expecting the included, links and meta members")
+	}
+
+	// The data can be unmarshalled more than once.
+	for i := 0; i < 2; i++ {
+		blog := new(Blog)
+		if err := doc.UnmarshalData(blog); err != nil {
+			t.Fatal(err)
+		}
+		if blog.ID != 5 || blog.CurrentPost == nil || blog.CurrentPost.Title != "Post" {
+			t.Fatalf("Was expecting blog 5 with its included post, got %#v", blog)
+		}
+	}
+
+	var blogs []*Blog
+	if err := doc.UnmarshalData(&blogs); err != jsonapi.ErrDataMismatch {
+		t.Fatalf("Was expecting ErrDataMismatch, got %v", err)
+	}
+}
+
+func TestDocument_many(t *testing.T) {
+	doc, err := jsonapi.UnmarshalDocument(strings.NewReader(`{
+		"data": [
+			{"type": "blogs", "id": "1", "attributes": {"title": "One"}},
+			{"type": "blogs", "id": "2", "attributes": {"title": "Two"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.DataKind() != jsonapi.ManyData || len(doc.Data()) != 2 {
+		t.Fatalf("Was expecting two resources, got %v", doc.Data())
+	}
+
+	var blogs []*Blog
+	if err := doc.UnmarshalData(&blogs); err != nil {
+		t.Fatal(err)
+	}
+	if len(blogs) != 2 || blogs[1].Title != "Two" {
+		t.Fatalf("Was expecting two blogs, got %#v", blogs)
+	}
+
+	if err := doc.UnmarshalData(new(Blog)); err != jsonapi.ErrDataMismatch {
+		t.Fatalf("Was expecting ErrDataMismatch, got %v", err)
+	}
+}
+
+func TestDocument_null(t *testing.T) {
+	doc, err := jsonapi.UnmarshalDocument(strings.NewReader(`{"data": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.DataKind() != jsonapi.NullData || doc.Data() != nil {
+		t.Fatalf("Was expecting null data, got %v", doc.DataKind())
+	}
+
+	blog := &Blog{Title: "Kept"}
+	if err := doc.UnmarshalData(blog); err != nil || blog.Title != "Kept" {
+		t.Fatalf("Was expecting the model to be untouched, got %#v, %v", blog, err)
+	}
+
+	blogs := []*Blog{blog}
+	if err := doc.UnmarshalData(&blogs); err != nil || len(blogs) != 0 {
+		t.Fatalf("Was expecting an empty slice, got %#v, %v", blogs, err)
+	}
+}
+
+func TestDocument_errorsAndMeta(t *testing.T) {
+	doc, err := jsonapi.UnmarshalDocument(strings.NewReader(`{
+		"errors": [{"status": "404", "title": "Not Found", "source": {"parameter": "id"}}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Kind() != jsonapi.ErrorsDocument || doc.DataKind() != jsonapi.NoData {
+		t.Fatalf("Was expecting an errors document, got %v", doc.Kind())
+	}
+	if doc.Errors[0].Source.Parameter != "id" {
+		t.Fatalf("Was expecting the error source, got %#v", doc.Errors[0].Source)
+	}
+	if err := doc.UnmarshalData(new(Blog)); err != jsonapi.ErrNoData {
+		t.Fatalf("Was expecting ErrNoData, got %v", err)
+	}
+
+	doc, err = jsonapi.UnmarshalDocument(strings.NewReader(`{"meta": {"deleted": 3}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Kind() != jsonapi.MetaDocument {
+		t.Fatalf("Was expecting a meta document, got %v", doc.Kind())
+	}
+
+	if _, err := jsonapi.UnmarshalDocument(strings.NewReader(`{"data": [], "errors": []}`)); err != jsonapi.ErrInvalidDocument {
+		t.Fatalf("Was expecting ErrInvalidDocument, got %v", err)
+	}
+}
+
+func TestDocument_roundTrip(t *testing.T) {
+	for _, in := range []string{
+		`{"data":null,"meta":{"total":0}}`,
+		`{"data":[]}`,
+		`{"data":{"type":"blogs","id":"1","attributes":{"title":"One"}},"jsonapi":{"version":"1.0"}}`,
+		`{"errors":[{"title":"Bad"}]}`,
+	} {
+		doc, err := jsonapi.UnmarshalDocument(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected, actual interface{}
+		json.Unmarshal([]byte(in), &expected)
+		json.NewDecoder(bytes.NewReader(out)).Decode(&actual)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("Was expecting %s, got %s", in, out)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// An Encoder writes JSON API documents to an output stream.
+//
+//	enc := jsonapi.NewEncoder(w,
+//		jsonapi.WithContext(r.Context()),
+//		jsonapi.WithIndent("", "  "),
+//		jsonapi.WithMeta(&jsonapi.Meta{"total": total}),
+//	)
+//	if err := enc.Encode(blogs); err != nil {
+//		http.Error(w, err.Error(), http.StatusInternalServerError)
+//	}
+type Encoder struct {
+	w    io.Writer
+	opts *options
+}
+
+// NewEncoder returns an Encoder that writes to w, configured by opts.
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	return &Encoder{w: w, opts: newOptions(opts)}
+}
+
+// Encode writes the document for models, which should be either a struct
+// pointer or a slice of struct pointers, as MarshalPayload does.
+func (e *Encoder) Encode(models interface{}) error {
+	w := newWalk(e.opts)
+
+	var payload Payloader
+	err := w.trace(SpanVisitModelGraph, nil, func() (err error) {
+		payload, err = e.marshal(w, models)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.encode(w, payload)
+}
+
+// EncodeErrors writes an errors document, as MarshalErrors does.
+func (e *Encoder) EncodeErrors(errorObjects []*ErrorObject) error {
+	return e.encode(newWalk(e.opts), &ErrorsPayload{Errors: errorObjects})
+}
+
+func (e *Encoder) encode(w *walk, v interface{}) error {
+	attributes := map[string]interface{}{
+		"resources": w.stats.Resources,
+		"included":  w.stats.Included,
+	}
+
+	return w.trace(SpanEncodeJSON, attributes, func() error {
+		return e.write(v)
+	})
+}
+
+func (e *Encoder) write(v interface{}) error {
+	out := e.w
+	if e.opts.stats != nil {
+		out = countingWriter{w: out, n: &e.opts.stats.BytesWritten}
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent(e.opts.prefix, e.opts.indent)
+	enc.SetEscapeHTML(e.opts.escapeHTML)
+
+	return enc.Encode(v)
+}
+
+// marshal builds the document for models according to the Encoder's
+// options.
+func (e *Encoder) marshal(w *walk, models interface{}) (Payloader, error) {
+	sideload := e.opts.includeMode != IncludeEmbedded
+
+	var payload Payloader
+
+	switch vals := reflect.ValueOf(models); vals.Kind() {
+	case reflect.Slice:
+		m, err := convertToSliceInterface(&models)
+		if err != nil {
+			return nil, err
+		}
+
+		many, err := marshalMany(w, m, sideload)
+		if err != nil {
+			return nil, err
+		}
+
+		if linkableModels, isLinkable := models.(Linkable); isLinkable {
+			jl := linkableModels.JSONAPILinks()
+			if er := jl.validate(); er != nil {
+				return nil, er
+			}
+			many.Links = linkableModels.JSONAPILinks()
+		}
+
+		if metableModels, ok := models.(Metable); ok {
+			many.Meta = metableModels.JSONAPIMeta()
+		}
+
+		payload = many
+	case reflect.Ptr:
+		// Check that the pointer was to a struct
+		if reflect.Indirect(vals).Kind() != reflect.Struct {
+			return nil, ErrUnexpectedType
+		}
+
+		one, err := marshalOne(w, models, sideload)
+		if err != nil {
+			return nil, err
+		}
+
+		payload = one
+	default:
+		return nil, ErrUnexpectedType
+	}
+
+	if e.opts.includeMode == IncludeNone {
+		payload.clearIncluded()
+		w.stats.Included = 0
+	}
+
+	if e.opts.links != nil {
+		if err := e.opts.links.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch p := payload.(type) {
+	case *OnePayload:
+		if e.opts.links != nil {
+			p.Links = e.opts.links
+		}
+		if e.opts.meta != nil {
+			p.Meta = e.opts.meta
+		}
+	case *ManyPayload:
+		if e.opts.links != nil {
+			p.Links = e.opts.links
+		}
+		if e.opts.meta != nil {
+			p.Meta = e.opts.meta
+		}
+	}
+
+	return payload, nil
+}
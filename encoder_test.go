@@ -0,0 +1,141 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestEncoder_includeModes(t *testing.T) {
+	for _, mode := range []jsonapi.IncludeMode{
+		jsonapi.IncludeSideloaded,
+		jsonapi.IncludeNone,
+		jsonapi.IncludeEmbedded,
+	} {
+		out := bytes.NewBuffer(nil)
+		if err := jsonapi.NewEncoder(out, jsonapi.WithIncludeMode(mode)).Encode(testBlog()); err != nil {
+			t.Fatal(err)
+		}
+
+		var jsonData map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+			t.Fatal(err)
+		}
+
+		_, hasIncluded := jsonData["included"]
+		if hasIncluded != (mode == jsonapi.IncludeSideloaded) {
+			t.Fatalf("Was not expecting included to be %v in mode %d", hasIncluded, mode)
+		}
+
+		currentPost := jsonData["data"].(map[string]interface{})["relationships"].(map[string]interface{})["current_post"].(map[string]interface{})["data"].(map[string]interface{})
+		_, hasAttributes := currentPost["attributes"]
+		if hasAttributes != (mode == jsonapi.IncludeEmbedded) {
+			t.Fatalf("Was not expecting relationship attributes to be %v in mode %d", hasAttributes, mode)
+		}
+	}
+}
+
+func TestEncoder_formatting(t *testing.T) {
+	blog := testBlog()
+	blog.Title = "<b>Bold</b>"
+
+	out := bytes.NewBuffer(nil)
+	enc := jsonapi.NewEncoder(out, jsonapi.WithIndent("", "  "), jsonapi.WithEscapeHTML(false))
+	if err := enc.Encode(blog); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "\n  \"data\": {") {
+		t.Fatalf("Was expecting indented output, got %s", out.String())
+	}
+	if !strings.Contains(out.String(), `"title": "<b>Bold</b>"`) {
+		t.Fatalf("Was expecting unescaped HTML, got %s", out.String())
+	}
+}
+
+func TestEncoder_topLevelLinksAndMeta(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	enc := jsonapi.NewEncoder(out,
+		jsonapi.WithLinks(&jsonapi.Links{"self": "https://example.com/api/blogs"}),
+		jsonapi.WithMeta(&jsonapi.Meta{"total": 1}),
+	)
+	if err := enc.Encode([]*Blog{testBlog()}); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+		t.Fatal(err)
+	}
+
+	links := jsonData["links"].(map[string]interface{})
+	if e, a := "https://example.com/api/blogs", links["self"]; e != a {
+		t.Fatalf("Was expecting self link `%s`, got `%v`", e, a)
+	}
+	meta := jsonData["meta"].(map[string]interface{})
+	if e, a := float64(1), meta["total"]; e != a {
+		t.Fatalf("Was expecting total %v, got %v", e, a)
+	}
+}
+
+func TestEncoder_invalidLinks(t *testing.T) {
+	enc := jsonapi.NewEncoder(bytes.NewBuffer(nil),
+		jsonapi.WithLinks(&jsonapi.Links{"self": 1}))
+	if err := enc.Encode(testBlog()); err == nil {
+		t.Fatal("Was expecting an error for invalid links")
+	}
+}
+
+func TestEncoder_maxDepth(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.NewEncoder(out, jsonapi.WithMaxDepth(1)).Encode(testBlog()); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := new(jsonapi.OnePayload)
+	if err := json.Unmarshal(out.Bytes(), payload); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, included := range payload.Included {
+		if included.Type != "posts" {
+			t.Fatalf("Was expecting only posts to be included, got %s", included.Type)
+		}
+		if _, ok := included.Relationships["comments"]; !ok {
+			t.Fatal("Was expecting included posts to keep their comments linkage")
+		}
+	}
+	if e, a := 2, len(payload.Included); e != a {
+		t.Fatalf("Was expecting %d included resources, got %d", e, a)
+	}
+}
+
+func TestEncoder_context(t *testing.T) {
+	ctx := context.WithValue(context.Background(), noteKey{}, "from context")
+	writer := &Writer{ID: "1", Email: "a@example.com"}
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.NewEncoder(out, jsonapi.WithContext(ctx)).Encode(writer); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "from context", writer.Note; e != a {
+		t.Fatalf("Was expecting the hook to see `%s`, got `%s`", e, a)
+	}
+}
+
+func TestEncoder_encodeErrors(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	enc := jsonapi.NewEncoder(out, jsonapi.WithIndent("", "\t"))
+	if err := enc.EncodeErrors([]*jsonapi.ErrorObject{{Title: "Bad"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "\n\t\"errors\": [") {
+		t.Fatalf("Was expecting an indented errors document, got %s", out.String())
+	}
+}
@@ -2,17 +2,28 @@ package jsonapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 )
 
+// ErrExpectedErrors is returned by UnmarshalErrors when the document has no
+// "errors" member.
+var ErrExpectedErrors = errors.New("document should have an errors member")
+
 // MarshalErrors writes a JSON API response using the given `[]error`.
 //
 // For more information on JSON API error payloads, see the spec here:
 // http://jsonapi.org/format/#document-top-level
 // and here: http://jsonapi.org/format/#error-objects.
 func MarshalErrors(w io.Writer, errorObjects []*ErrorObject) error {
-	return json.NewEncoder(w).Encode(&ErrorsPayload{Errors: errorObjects})
+	return NewEncoder(w).EncodeErrors(errorObjects)
+}
+
+// UnmarshalErrors reads an errors document, as written by MarshalErrors, and
+// returns its error objects.
+func UnmarshalErrors(in io.Reader) ([]*ErrorObject, error) {
+	return NewDecoder(in).DecodeErrors()
 }
 
 // ErrorsPayload is a serializer struct for representing a valid JSON API errors payload.
@@ -61,9 +72,65 @@ func (e *ErrorObject) Error() string {
 type ErrorSource struct {
 	Pointer   string `json:"pointer,omitempty"`
 	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
 }
 
-// ErrorLink is an object providing access to the `about` detail of an error.
+// ErrorLink is an object providing access to the `about` detail of an error,
+// and to the `type` of error it is. A link given as a link object keeps its
+// meta, which is written back as a link object.
 type ErrorLink struct {
-	About string `json:"about,omitempty"`
+	About     string `json:"about,omitempty"`
+	AboutMeta Meta   `json:"-"`
+	Type      string `json:"type,omitempty"`
+	TypeMeta  Meta   `json:"-"`
+}
+
+// MarshalJSON encodes each of an error's links as a URL, or as a link object
+// when it has meta.
+func (l ErrorLink) MarshalJSON() ([]byte, error) {
+	links := Links{}
+	for name, link := range map[string]Link{
+		"about": {Href: l.About, Meta: l.AboutMeta},
+		"type":  {Href: l.Type, Meta: l.TypeMeta},
+	} {
+		switch {
+		case len(link.Meta) > 0:
+			links[name] = link
+		case link.Href != "":
+			links[name] = link.Href
+		}
+	}
+
+	return json.Marshal(links)
+}
+
+// UnmarshalJSON decodes an error's links, each given either as a URL or as a
+// link object with an "href".
+func (l *ErrorLink) UnmarshalJSON(data []byte) error {
+	var links Links
+	if err := json.Unmarshal(data, &links); err != nil {
+		return err
+	}
+
+	targets := map[string]struct {
+		href *string
+		meta *Meta
+	}{
+		"about": {&l.About, &l.AboutMeta},
+		"type":  {&l.Type, &l.TypeMeta},
+	}
+	for name, target := range targets {
+		link, err := links.Link(name)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			continue
+		}
+
+		*target.href = link.Href
+		*target.meta = link.Meta
+	}
+
+	return nil
 }
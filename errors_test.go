@@ -116,3 +116,73 @@ func TestMarshalErrorsWritesTheExpectedPayload(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalErrors(t *testing.T) {
+	in := bytes.NewBufferString(`{
+		"errors": [{
+			"id": "1",
+			"status": "422",
+			"code": "E1100",
+			"title": "Invalid Attribute",
+			"detail": "Title is required",
+			"links": {"about": {"href": "https://example.com/errors/E1100", "meta": {"v": 1}}, "type": "https://example.com/types/invalid"},
+			"source": {"pointer": "/data/attributes/title", "header": "Content-Type"},
+			"meta": {"field": "title"}
+		}]
+	}`)
+
+	errs, err := jsonapi.UnmarshalErrors(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*jsonapi.ErrorObject{{
+		ID:     "1",
+		Status: "422",
+		Code:   "E1100",
+		Title:  "Invalid Attribute",
+		Detail: "Title is required",
+		Links: &jsonapi.ErrorLink{
+			About:     "https://example.com/errors/E1100",
+			AboutMeta: jsonapi.Meta{"v": 1.0},
+			Type:      "https://example.com/types/invalid",
+		},
+		Source: &jsonapi.ErrorSource{Pointer: "/data/attributes/title", Header: "Content-Type"},
+		Meta:   &map[string]interface{}{"field": "title"},
+	}}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("Was expecting %#v, got %#v", expected[0], errs[0])
+	}
+}
+
+func TestUnmarshalErrorsRoundTrip(t *testing.T) {
+	in := []*jsonapi.ErrorObject{{
+		Title:  "Bad",
+		Status: "400",
+		Links: &jsonapi.ErrorLink{
+			About:    "https://example.com/errors/bad",
+			Type:     "https://example.com/types/bad",
+			TypeMeta: jsonapi.Meta{"version": "2"},
+		},
+		Source: &jsonapi.ErrorSource{Parameter: "sort"},
+	}}
+
+	buf := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalErrors(buf, in); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := jsonapi.UnmarshalErrors(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("Was expecting %#v, got %#v", in[0], out[0])
+	}
+}
+
+func TestUnmarshalErrorsExpectsErrors(t *testing.T) {
+	if _, err := jsonapi.UnmarshalErrors(bytes.NewBufferString(`{"data": null}`)); err != jsonapi.ErrExpectedErrors {
+		t.Fatalf("Was expecting ErrExpectedErrors, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build go1.18
+// +build go1.18
+
+package jsonapi_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+// fuzzSeeds returns documents exercising attributes of every kind, related
+// and included resources, and malformed members.
+func fuzzSeeds(t testing.TB) [][]byte {
+	blog, err := ioutil.ReadAll(samplePayload())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	marshalled := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(marshalled, testBlog()); err != nil {
+		t.Fatal(err)
+	}
+
+	return [][]byte{
+		blog,
+		marshalled.Bytes(),
+		[]byte(cyclicStoryPayload),
+		[]byte(playlistPayload),
+		[]byte(`{"data": null}`),
+		[]byte(`{"data": [null], "included": [null]}`),
+		[]byte(`{"data": {"type": "blogs", "id": "1", "relationships": {"posts": {"data": [null, 1, {"type": "posts"}]}}}}`),
+		[]byte(`{"data": {"type": "blogs", "id": "1e400", "attributes": {"title": {"a": [1]}, "view_count": 1e30}}}`),
+		[]byte(`{"data": {"type": "schedules", "id": "1", "attributes": {"starts": 5, "millis": "x", "holidays": [true], "reminders": {}}}}`),
+		[]byte(`{"data": {"type": "inventories", "id": "1", "attributes": {"counts": [1], "labels": {"x": "y"}, "grid": [[1, 2, 3]], "blob": "!!", "items": [{"sku": 1}], "origin": "here", "sizes": {"a": null}}}}`),
+		[]byte(`{"data": {"type": "numeric", "id": "1", "attributes": {"int": -1e20, "uint": -1, "float": "1", "cmplx": 1}}}`),
+		[]byte(`{"data": {"type": "blogs", "id": "1", "attributes": {"title": true}}}`),
+		[]byte(`{"data": [{"type": "blogs", "id": "1", "relationships": {"current_post": {"data": {"type": "posts", "id": "-1"}}}}]}`),
+	}
+}
+
+// fuzzModels are the models each fuzzed document is unmarshalled into.
+var fuzzModels = []reflect.Type{
+	reflect.TypeOf(Blog{}),
+	reflect.TypeOf(Schedule{}),
+	reflect.TypeOf(Inventory{}),
+	reflect.TypeOf(Numeric{}),
+	reflect.TypeOf(WithPointer{}),
+	reflect.TypeOf(Story{}),
+	reflect.TypeOf(Playlist{}),
+	reflect.TypeOf(CustomAttributeTypes{}),
+}
+
+func FuzzUnmarshalPayload(f *testing.F) {
+	for _, seed := range fuzzSeeds(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, model := range fuzzModels {
+			if err := jsonapi.UnmarshalPayload(bytes.NewReader(data), reflect.New(model).Interface()); err != nil {
+				_ = err.Error()
+			}
+		}
+	})
+}
+
+func FuzzUnmarshalManyPayload(f *testing.F) {
+	for _, seed := range fuzzSeeds(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, model := range fuzzModels {
+			if _, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(data), reflect.PtrTo(model)); err != nil {
+				_ = err.Error()
+			}
+		}
+	})
+}
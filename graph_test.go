@@ -0,0 +1,171 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+const cyclicStoryPayload = `{
+	"data": {
+		"type": "stories",
+		"id": "1",
+		"attributes": {"title": "First"},
+		"relationships": {"author": {"data": {"type": "people", "id": "9"}}}
+	},
+	"included": [
+		{
+			"type": "people",
+			"id": "9",
+			"attributes": {"name": "Ann"},
+			"relationships": {
+				"stories": {"data": [{"type": "stories", "id": "1"}, {"type": "stories", "id": "2"}]},
+				"friend": {"data": {"type": "people", "id": "9"}}
+			}
+		},
+		{
+			"type": "stories",
+			"id": "2",
+			"attributes": {"title": "Second"},
+			"relationships": {"author": {"data": {"type": "people", "id": "9"}}}
+		}
+	]
+}`
+
+func TestUnmarshalCyclicGraph(t *testing.T) {
+	story := new(Story)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(cyclicStoryPayload), story); err != nil {
+		t.Fatal(err)
+	}
+
+	author := story.Author
+	if author == nil || author.Name != "Ann" || len(author.Stories) != 2 {
+		t.Fatalf("Was expecting the author with two stories, got %#v", author)
+	}
+	if author.Stories[0] != story {
+		t.Fatal("Was expecting the author's first story to be the primary story")
+	}
+	if author.Stories[1].Title != "Second" || author.Stories[1].Author != author {
+		t.Fatal("Was expecting the second story to share the author")
+	}
+	if author.Friend != author {
+		t.Fatal("Was expecting the self reference to be the same model")
+	}
+}
+
+func TestUnmarshalManySharesModels(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [
+			{"type": "stories", "id": "1", "relationships": {"author": {"data": {"type": "people", "id": "9"}}}},
+			{"type": "stories", "id": "2", "relationships": {"author": {"data": {"type": "people", "id": "9"}}}}
+		],
+		"included": [
+			{"type": "people", "id": "9", "attributes": {"name": "Ann"},
+				"relationships": {"stories": {"data": [{"type": "stories", "id": "2"}]}}}
+		]
+	}`)
+
+	stories, err := jsonapi.UnmarshalManyPayload(in, reflect.TypeOf(new(Story)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := stories[0].(*Story), stories[1].(*Story)
+	if first.Author == nil || first.Author != second.Author {
+		t.Fatal("Was expecting both stories to share the author")
+	}
+	if first.Author.Stories[0] != second {
+		t.Fatal("Was expecting the author's story to be the primary story")
+	}
+}
+
+func cyclicStory() *Story {
+	author := &Person{ID: "9", Name: "Ann"}
+	first := &Story{ID: "1", Title: "First", Author: author}
+	second := &Story{ID: "2", Title: "Second", Author: author}
+	author.Stories = []*Story{first, second}
+	author.Friend = author
+
+	return first
+}
+
+func TestMarshalCyclicGraph(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, cyclicStory()); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := new(jsonapi.OnePayload)
+	if err := json.NewDecoder(out).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var included []string
+	for _, n := range payload.Included {
+		included = append(included, n.Type+","+n.ID)
+	}
+	sort.Strings(included)
+	if e, a := []string{"people,9", "stories,2"}, included; !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting %v to be included, got %v", e, a)
+	}
+
+	// The marshalled document unmarshals back into the same graph.
+	buf := bytes.NewBuffer(nil)
+	json.NewEncoder(buf).Encode(payload)
+
+	story := new(Story)
+	if err := jsonapi.UnmarshalPayload(buf, story); err != nil {
+		t.Fatal(err)
+	}
+	if story.Author.Stories[0] != story || story.Author.Friend != story.Author {
+		t.Fatal("Was expecting the cycles to be restored")
+	}
+}
+
+func TestMarshalCyclicGraphEmbedded(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalOnePayloadEmbedded(out, cyclicStory()); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Data struct {
+			Relationships struct {
+				Author struct {
+					Data struct {
+						Attributes    map[string]interface{}
+						Relationships struct {
+							Stories struct {
+								Data []map[string]interface{}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if err := json.NewDecoder(out).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	author := doc.Data.Relationships.Author.Data
+	if author.Attributes["name"] != "Ann" {
+		t.Fatalf("Was expecting the author to be embedded, got %v", author.Attributes)
+	}
+
+	stories := author.Relationships.Stories.Data
+	if len(stories) != 2 {
+		t.Fatalf("Was expecting two stories, got %v", stories)
+	}
+	if _, ok := stories[0]["attributes"]; ok {
+		t.Fatal("Was expecting the primary story to be written as resource linkage")
+	}
+	if _, ok := stories[1]["attributes"]; !ok {
+		t.Fatal("Was expecting the second story to be embedded")
+	}
+}
@@ -0,0 +1,41 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+)
+
+// BeforeMarshaler is implemented by models that need to prepare themselves
+// before being serialized, e.g. to compute derived attributes. BeforeMarshal
+// is invoked for every resource, including related and included resources,
+// before its fields are read. Returning an error aborts the marshal.
+type BeforeMarshaler interface {
+	BeforeMarshal(ctx context.Context) error
+}
+
+// AfterUnmarshaler is implemented by models that need to post-process their
+// fields after being deserialized, e.g. to normalise inputs. AfterUnmarshal
+// is invoked for every resource, including related and included resources,
+// once its fields are set and before it is validated. Returning an error
+// aborts the unmarshal.
+type AfterUnmarshaler interface {
+	AfterUnmarshal(ctx context.Context) error
+}
+
+// HookError is returned when a BeforeMarshal or AfterUnmarshal hook fails.
+// Pointer is the JSON pointer of the resource whose hook failed; when
+// marshaling it is the resource's position within the primary data, e.g.
+// "/data/relationships/author/data".
+type HookError struct {
+	Pointer string
+	Err     error
+}
+
+func (he *HookError) Error() string {
+	return fmt.Sprintf("jsonapi: hook failed for %s: %v", he.Pointer, he.Err)
+}
+
+// Unwrap returns the error returned by the hook.
+func (he *HookError) Unwrap() error {
+	return he.Err
+}
@@ -0,0 +1,110 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestBeforeMarshalHook(t *testing.T) {
+	article := &Article{
+		ID:     "1",
+		Body:   "A rather long article body",
+		Writer: &Writer{ID: "2", Email: "writer@example.com"},
+	}
+
+	payload, err := jsonapi.Marshal(article)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := payload.(*jsonapi.OnePayload).Data
+	if e, a := "A rather l", data.Attributes["excerpt"]; e != a {
+		t.Fatalf("Was expecting excerpt `%s`, got `%v`", e, a)
+	}
+}
+
+func TestBeforeMarshalHook_errorOnRelated(t *testing.T) {
+	article := &Article{ID: "1", Writer: &Writer{ID: "2"}}
+
+	err := jsonapi.MarshalPayload(bytes.NewBuffer(nil), article)
+	hookErr, ok := err.(*jsonapi.HookError)
+	if !ok {
+		t.Fatalf("Was expecting a HookError, got %T: %v", err, err)
+	}
+	if e, a := "/data/relationships/writer/data", hookErr.Pointer; e != a {
+		t.Fatalf("Was expecting pointer %s, got %s", e, a)
+	}
+	if hookErr.Err != errWriterEmail {
+		t.Fatalf("Was expecting the hook's error to be wrapped, got %v", err)
+	}
+}
+
+func TestAfterUnmarshalHook_included(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "articles",
+			"id": "1",
+			"relationships": {
+				"writer": {"data": {"type": "writers", "id": "2"}}
+			}
+		},
+		"included": [
+			{"type": "writers", "id": "2", "attributes": {"email": "  Writer@Example.COM "}}
+		]
+	}`
+
+	out := new(Article)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "writer@example.com", out.Writer.Email; e != a {
+		t.Fatalf("Was expecting email `%s`, got `%s`", e, a)
+	}
+}
+
+func TestAfterUnmarshalHook_identifier(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "articles",
+			"id": "1",
+			"relationships": {
+				"writer": {"data": {"type": "writers", "id": "2"}}
+			}
+		}
+	}`
+
+	out := new(Article)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), out); err != nil {
+		t.Fatalf("Was not expecting the hook of an identifier to run, got %v", err)
+	}
+
+	if e, a := "2", out.Writer.ID; e != a {
+		t.Fatalf("Was expecting writer `%s`, got `%s`", e, a)
+	}
+}
+
+func TestAfterUnmarshalHook_error(t *testing.T) {
+	in := `{
+		"data": [
+			{"type": "writers", "id": "1", "attributes": {"email": "one@example.com"}},
+			{"type": "writers", "id": "2", "attributes": {"email": " "}}
+		]
+	}`
+
+	_, err := jsonapi.UnmarshalManyPayload(strings.NewReader(in), reflect.TypeOf(new(Writer)))
+	hookErr, ok := err.(*jsonapi.HookError)
+	if !ok {
+		t.Fatalf("Was expecting a HookError, got %T: %v", err, err)
+	}
+	if e, a := "/data/1", hookErr.Pointer; e != a {
+		t.Fatalf("Was expecting pointer %s, got %s", e, a)
+	}
+	if hookErr.Err != errWriterEmail {
+		t.Fatalf("Was expecting %v, got %v", errWriterEmail, hookErr.Err)
+	}
+}
@@ -0,0 +1,496 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// LimitExceededCode is the Code of the ErrorObject returned when a document
+// exceeds one of a Decoder's Limits.
+const LimitExceededCode = "limit_exceeded"
+
+// Limits bound the documents a Decoder accepts, so that a hostile request
+// body can't exhaust memory or time. A Decoder stops reading a document as
+// soon as it exceeds a limit, before the rest of it is read or decoded, and
+// fails with an *ErrorObject whose Status is 413 for MaxBodyBytes and 400
+// otherwise. A zero field means no limit.
+type Limits struct {
+	// MaxBodyBytes is the size of the document in bytes.
+	MaxBodyBytes int64
+
+	// MaxResources is the number of resources in the document, primary
+	// and included.
+	MaxResources int
+
+	// MaxIncluded is the number of included resources.
+	MaxIncluded int
+
+	// MaxRelationshipLinkage is the number of resources in the linkage of
+	// a single to-many relationship.
+	MaxRelationshipLinkage int
+
+	// MaxAttributeDepth is how deeply objects and arrays may nest within an
+	// attribute or relationship.
+	MaxAttributeDepth int
+}
+
+// DefaultLimits are the Limits of a Decoder created without WithLimits, and
+// so of UnmarshalPayload and UnmarshalManyPayload.
+var DefaultLimits = Limits{
+	MaxBodyBytes:           10 << 20,
+	MaxResources:           10000,
+	MaxIncluded:            5000,
+	MaxRelationshipLinkage: 1000,
+	MaxAttributeDepth:      32,
+}
+
+// WithLimits sets the Limits of a Decoder. The default is DefaultLimits;
+// Limits{} lifts them all.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// limitError builds the ErrorObject for a document exceeding limit.
+func limitError(status int, detail string, pointer string, limit int64) *ErrorObject {
+	e := &ErrorObject{
+		Status: strconv.Itoa(status),
+		Code:   LimitExceededCode,
+		Title:  http.StatusText(status),
+		Detail: detail,
+		Meta:   &map[string]interface{}{"limit": limit},
+	}
+	if pointer != "" {
+		e.Source = &ErrorSource{Pointer: pointer}
+	}
+
+	return e
+}
+
+// limitedReader reads from r until more than limit bytes have been read,
+// then fails with a 413 ErrorObject.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func limitReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+
+	return &limitedReader{r: r, limit: limit}
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if lr.n > lr.limit {
+		return 0, lr.err()
+	}
+
+	if remaining := lr.limit - lr.n + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := lr.r.Read(p)
+	lr.n += int64(n)
+	if lr.n > lr.limit {
+		return 0, lr.err()
+	}
+
+	return n, err
+}
+
+func (lr *limitedReader) err() error {
+	return limitError(
+		http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("The document is larger than %d bytes.", lr.limit),
+		"",
+		lr.limit,
+	)
+}
+
+// checkLimits rejects a document whose primary and included resources
+// exceed the limits. one tells whether the primary data is a single
+// resource rather than an array. It covers documents decoded before the
+// limits were known, e.g. by DecodeDocument; a Decoder's own reads are
+// checked as they go by a limitScanner.
+func checkLimits(limits Limits, data []*ResourceObj, one bool, included []*ResourceObj) error {
+	if limits.MaxIncluded > 0 && len(included) > limits.MaxIncluded {
+		return includedError(limits)
+	}
+
+	if limits.MaxResources > 0 && len(data)+len(included) > limits.MaxResources {
+		return resourcesError(limits)
+	}
+
+	for i, node := range data {
+		pointer := fmt.Sprintf("/data/%d", i)
+		if one {
+			pointer = "/data"
+		}
+		if err := checkNodeLimits(limits, node, pointer); err != nil {
+			return err
+		}
+	}
+	for i, node := range included {
+		if err := checkNodeLimits(limits, node, fmt.Sprintf("/included/%d", i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkNodeLimits(limits Limits, node *ResourceObj, pointer string) error {
+	if node == nil {
+		return nil
+	}
+
+	if limits.MaxAttributeDepth > 0 {
+		for name, value := range node.Attributes {
+			if valueDepth(value, limits.MaxAttributeDepth) > limits.MaxAttributeDepth {
+				return depthError(limits, fmt.Sprintf("%s/attributes/%s", pointer, name))
+			}
+		}
+	}
+
+	for name, value := range node.Relationships {
+		relationshipPointer := fmt.Sprintf("%s/relationships/%s", pointer, name)
+
+		if limits.MaxAttributeDepth > 0 && valueDepth(value, limits.MaxAttributeDepth) > limits.MaxAttributeDepth {
+			return depthError(limits, relationshipPointer)
+		}
+
+		relationship, _ := value.(map[string]interface{})
+		linkage, _ := relationship["data"].([]interface{})
+		if limits.MaxRelationshipLinkage > 0 && len(linkage) > limits.MaxRelationshipLinkage {
+			return linkageError(limits, relationshipPointer+"/data")
+		}
+	}
+
+	return nil
+}
+
+func includedError(limits Limits) error {
+	return limitError(
+		http.StatusBadRequest,
+		fmt.Sprintf("The document includes more than %d resources.", limits.MaxIncluded),
+		"/included",
+		int64(limits.MaxIncluded),
+	)
+}
+
+func resourcesError(limits Limits) error {
+	return limitError(
+		http.StatusBadRequest,
+		fmt.Sprintf("The document has more than %d resources.", limits.MaxResources),
+		"",
+		int64(limits.MaxResources),
+	)
+}
+
+func linkageError(limits Limits, pointer string) error {
+	return limitError(
+		http.StatusBadRequest,
+		fmt.Sprintf("The relationship links more than %d resources.", limits.MaxRelationshipLinkage),
+		pointer,
+		int64(limits.MaxRelationshipLinkage),
+	)
+}
+
+func depthError(limits Limits, pointer string) error {
+	return limitError(
+		http.StatusBadRequest,
+		fmt.Sprintf("The member nests objects and arrays more than %d levels deep.", limits.MaxAttributeDepth),
+		pointer,
+		int64(limits.MaxAttributeDepth),
+	)
+}
+
+// valueDepth returns how deeply objects and arrays nest within a decoded
+// JSON value, counting no further than max+1.
+func valueDepth(value interface{}, max int) int {
+	if max < 0 {
+		return 0
+	}
+
+	var deepest int
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			if d := valueDepth(child, max-1); d > deepest {
+				deepest = d
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if d := valueDepth(child, max-1); d > deepest {
+				deepest = d
+			}
+		}
+	default:
+		return 0
+	}
+
+	return deepest + 1
+}
+
+// limitScanner follows the structure of a document as it is read, so that
+// a Decoder stops at the first byte that takes the document over its count
+// or depth limits rather than once it is fully decoded.
+type limitScanner struct {
+	r      io.Reader
+	limits Limits
+	err    error
+
+	frames  []scanFrame
+	str     bool
+	escaped bool
+	key     []byte
+	isKey   bool
+
+	// dataArray tells whether the primary data is an array of resources.
+	dataArray bool
+	resources int
+	included  int
+}
+
+// scanFrame is an object or array being read, at path within the document.
+type scanFrame struct {
+	object bool
+	path   []string
+	// key is the name of the object member being read.
+	key string
+	// expectKey tells whether the next string of an object is a key.
+	expectKey bool
+	// count is the number of elements of an array started so far.
+	count int
+	// pending tells whether the next value of an array starts an element.
+	pending bool
+}
+
+// scanLimits returns r checked against the count and depth limits, or r
+// itself if there are none.
+func scanLimits(r io.Reader, limits Limits) io.Reader {
+	if limits.MaxResources <= 0 && limits.MaxIncluded <= 0 &&
+		limits.MaxRelationshipLinkage <= 0 && limits.MaxAttributeDepth <= 0 {
+		return r
+	}
+
+	return &limitScanner{r: r, limits: limits}
+}
+
+func (ls *limitScanner) Read(p []byte) (int, error) {
+	if ls.err != nil {
+		return 0, ls.err
+	}
+
+	n, err := ls.r.Read(p)
+	for _, c := range p[:n] {
+		if ls.err = ls.scan(c); ls.err != nil {
+			return 0, ls.err
+		}
+	}
+
+	return n, err
+}
+
+// scan follows the document through its next byte c.
+func (ls *limitScanner) scan(c byte) error {
+	if ls.str {
+		switch {
+		case ls.escaped:
+			ls.escaped = false
+		case c == '\\':
+			ls.escaped = true
+		case c == '"':
+			ls.str = false
+			if ls.isKey {
+				ls.top().key = unquoteKey(ls.key)
+			}
+			return nil
+		}
+		if ls.isKey {
+			ls.key = append(ls.key, c)
+		}
+		return nil
+	}
+
+	switch c {
+	case ' ', '\t', '\n', '\r':
+		return nil
+	case ':':
+		if top := ls.top(); top != nil {
+			top.expectKey = false
+		}
+		return nil
+	case ',':
+		if top := ls.top(); top != nil {
+			top.expectKey = top.object
+			top.pending = !top.object
+		}
+		return nil
+	case '}', ']':
+		if len(ls.frames) > 0 {
+			ls.frames = ls.frames[:len(ls.frames)-1]
+		}
+		return nil
+	}
+
+	top := ls.top()
+	if c == '"' {
+		ls.str = true
+		ls.isKey = top != nil && top.object && top.expectKey
+		ls.key = ls.key[:0]
+		if ls.isKey {
+			return nil
+		}
+	}
+
+	// c starts a value.
+	var path []string
+	if top != nil {
+		if !top.object {
+			if !top.pending {
+				return nil
+			}
+			top.pending = false
+			top.count++
+			if err := ls.element(top); err != nil {
+				return err
+			}
+		}
+		if c != '{' && c != '[' {
+			return nil
+		}
+		path = append(append([]string{}, top.path...), top.segment())
+	}
+
+	if c != '{' && c != '[' {
+		return nil
+	}
+
+	frame := scanFrame{object: c == '{', path: path, expectKey: c == '{', pending: c == '['}
+	ls.frames = append(ls.frames, frame)
+
+	return ls.open(&ls.frames[len(ls.frames)-1])
+}
+
+func (ls *limitScanner) top() *scanFrame {
+	if len(ls.frames) == 0 {
+		return nil
+	}
+
+	return &ls.frames[len(ls.frames)-1]
+}
+
+// segment returns the path segment of the value being read in f.
+func (f *scanFrame) segment() string {
+	if f.object {
+		return f.key
+	}
+
+	return strconv.Itoa(f.count - 1)
+}
+
+// open checks the object or array f, which has just been opened.
+func (ls *limitScanner) open(f *scanFrame) error {
+	if len(f.path) == 1 && f.path[0] == "data" {
+		if !f.object {
+			ls.dataArray = true
+		} else if err := ls.resource(); err != nil {
+			return err
+		}
+	}
+
+	if member := ls.memberLen(f.path); member > 0 && ls.limits.MaxAttributeDepth > 0 &&
+		len(f.path)-member+1 > ls.limits.MaxAttributeDepth {
+		return depthError(ls.limits, "/"+strings.Join(f.path[:member], "/"))
+	}
+
+	return nil
+}
+
+// element checks the element of the array f that has just started.
+func (ls *limitScanner) element(f *scanFrame) error {
+	path := f.path
+
+	switch {
+	case len(path) == 1 && path[0] == "included":
+		ls.included++
+		if ls.limits.MaxIncluded > 0 && ls.included > ls.limits.MaxIncluded {
+			return includedError(ls.limits)
+		}
+		return ls.resource()
+	case len(path) == 1 && path[0] == "data":
+		return ls.resource()
+	}
+
+	resource := ls.resourceLen(path)
+	if resource > 0 && len(path) == resource+3 &&
+		path[resource] == "relationships" && path[resource+2] == "data" &&
+		ls.limits.MaxRelationshipLinkage > 0 && f.count > ls.limits.MaxRelationshipLinkage {
+		return linkageError(ls.limits, "/"+strings.Join(path, "/"))
+	}
+
+	return nil
+}
+
+func (ls *limitScanner) resource() error {
+	ls.resources++
+	if ls.limits.MaxResources > 0 && ls.resources > ls.limits.MaxResources {
+		return resourcesError(ls.limits)
+	}
+
+	return nil
+}
+
+// resourceLen returns the length of the path of the primary or included
+// resource that path is within, or 0 if it is within none.
+func (ls *limitScanner) resourceLen(path []string) int {
+	switch {
+	case len(path) >= 1 && path[0] == "data" && !ls.dataArray:
+		return 1
+	case len(path) >= 2 && (path[0] == "data" || path[0] == "included"):
+		return 2
+	}
+
+	return 0
+}
+
+// memberLen returns the length of the path of the attribute or relationship
+// that path is within, or 0 if it is within none.
+func (ls *limitScanner) memberLen(path []string) int {
+	resource := ls.resourceLen(path)
+	if resource == 0 || len(path) < resource+2 {
+		return 0
+	}
+
+	if path[resource] == "attributes" || path[resource] == "relationships" {
+		return resource + 2
+	}
+
+	return 0
+}
+
+// unquoteKey returns the name of an object key read as raw JSON, without its
+// quotes.
+func unquoteKey(raw []byte) string {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw)
+	}
+
+	var key string
+	if err := json.Unmarshal(append(append([]byte{'"'}, raw...), '"'), &key); err != nil {
+		return string(raw)
+	}
+
+	return key
+}
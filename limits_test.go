@@ -0,0 +1,212 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+// limitErrorOf returns err as the ErrorObject of an exceeded limit.
+func limitErrorOf(t *testing.T, err error) *jsonapi.ErrorObject {
+	t.Helper()
+
+	e, ok := err.(*jsonapi.ErrorObject)
+	if !ok {
+		t.Fatalf("Was expecting an *ErrorObject, got %#v", err)
+	}
+	if e.Code != jsonapi.LimitExceededCode {
+		t.Fatalf("Was expecting code %q, got %q", jsonapi.LimitExceededCode, e.Code)
+	}
+
+	return e
+}
+
+// blogWithPosts returns a blog document relating and including n posts.
+func blogWithPosts(n int) string {
+	linkage := make([]string, n)
+	included := make([]string, n)
+	for i := range linkage {
+		linkage[i] = fmt.Sprintf(`{"type": "posts", "id": "%d"}`, i+1)
+		included[i] = fmt.Sprintf(`{"type": "posts", "id": "%d", "attributes": {"title": "Post"}}`, i+1)
+	}
+
+	return fmt.Sprintf(`{
+		"data": {
+			"type": "blogs",
+			"id": "1",
+			"relationships": {"posts": {"data": [%s]}}
+		},
+		"included": [%s]
+	}`, strings.Join(linkage, ","), strings.Join(included, ","))
+}
+
+func TestLimitsMaxBodyBytes(t *testing.T) {
+	payload := blogWithPosts(3)
+	dec := jsonapi.NewDecoder(strings.NewReader(payload), jsonapi.WithLimits(jsonapi.Limits{
+		MaxBodyBytes: int64(len(payload) - 1),
+	}))
+
+	e := limitErrorOf(t, dec.Decode(new(Blog)))
+	if e.Status != "413" {
+		t.Fatalf("Was expecting status 413, got %q", e.Status)
+	}
+}
+
+func TestLimitsMaxBodyBytesExact(t *testing.T) {
+	payload := blogWithPosts(3)
+	dec := jsonapi.NewDecoder(strings.NewReader(payload), jsonapi.WithLimits(jsonapi.Limits{
+		MaxBodyBytes: int64(len(payload)),
+	}))
+
+	blog := new(Blog)
+	if err := dec.Decode(blog); err != nil {
+		t.Fatal(err)
+	}
+	if len(blog.Posts) != 3 {
+		t.Fatalf("Was expecting 3 posts, got %d", len(blog.Posts))
+	}
+}
+
+func TestLimitsMaxIncluded(t *testing.T) {
+	dec := jsonapi.NewDecoder(strings.NewReader(blogWithPosts(3)), jsonapi.WithLimits(jsonapi.Limits{
+		MaxIncluded: 2,
+	}))
+
+	e := limitErrorOf(t, dec.Decode(new(Blog)))
+	if e.Status != "400" {
+		t.Fatalf("Was expecting status 400, got %q", e.Status)
+	}
+	if e.Source == nil || e.Source.Pointer != "/included" {
+		t.Fatalf("Was expecting the pointer /included, got %#v", e.Source)
+	}
+}
+
+func TestLimitsMaxIncludedEarly(t *testing.T) {
+	payload := blogWithPosts(3)
+	third := strings.LastIndex(payload, `{"type": "posts"`)
+	truncated := payload[:third+1]
+
+	dec := jsonapi.NewDecoder(strings.NewReader(truncated), jsonapi.WithLimits(jsonapi.Limits{
+		MaxIncluded: 2,
+	}))
+
+	e := limitErrorOf(t, dec.Decode(new(Blog)))
+	if e.Source == nil || e.Source.Pointer != "/included" {
+		t.Fatalf("Was expecting the pointer /included, got %#v", e.Source)
+	}
+}
+
+func TestLimitsMaxAttributeDepthEarly(t *testing.T) {
+	truncated := `{"data": {"type": "inventories", "id": "1", "attributes": {"extra": {"a": [{"b": [`
+
+	dec := jsonapi.NewDecoder(strings.NewReader(truncated), jsonapi.WithLimits(jsonapi.Limits{
+		MaxAttributeDepth: 3,
+	}))
+	e := limitErrorOf(t, dec.Decode(new(Inventory)))
+	if e.Source == nil || e.Source.Pointer != "/data/attributes/extra" {
+		t.Fatalf("Was expecting the pointer of the attribute, got %#v", e.Source)
+	}
+}
+
+func TestLimitsMaxResources(t *testing.T) {
+	dec := jsonapi.NewDecoder(strings.NewReader(blogWithPosts(3)), jsonapi.WithLimits(jsonapi.Limits{
+		MaxResources: 3,
+	}))
+
+	e := limitErrorOf(t, dec.Decode(new(Blog)))
+	if e.Status != "400" {
+		t.Fatalf("Was expecting status 400, got %q", e.Status)
+	}
+}
+
+func TestLimitsMaxRelationshipLinkage(t *testing.T) {
+	dec := jsonapi.NewDecoder(strings.NewReader(blogWithPosts(3)), jsonapi.WithLimits(jsonapi.Limits{
+		MaxRelationshipLinkage: 2,
+	}))
+
+	e := limitErrorOf(t, dec.Decode(new(Blog)))
+	if e.Source == nil || e.Source.Pointer != "/data/relationships/posts/data" {
+		t.Fatalf("Was expecting the pointer of the relationship linkage, got %#v", e.Source)
+	}
+}
+
+func TestLimitsMaxAttributeDepth(t *testing.T) {
+	payload := `{"data": {"type": "inventories", "id": "1", "attributes": {"extra": {"a": [{"b": [1]}]}}}}`
+
+	dec := jsonapi.NewDecoder(strings.NewReader(payload), jsonapi.WithLimits(jsonapi.Limits{
+		MaxAttributeDepth: 3,
+	}))
+	e := limitErrorOf(t, dec.Decode(new(Inventory)))
+	if e.Source == nil || e.Source.Pointer != "/data/attributes/extra" {
+		t.Fatalf("Was expecting the pointer of the attribute, got %#v", e.Source)
+	}
+
+	dec = jsonapi.NewDecoder(strings.NewReader(payload), jsonapi.WithLimits(jsonapi.Limits{
+		MaxAttributeDepth: 4,
+	}))
+	if err := dec.Decode(new(Inventory)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimitsMany(t *testing.T) {
+	payload := `{"data": [
+		{"type": "blogs", "id": "1"},
+		{"type": "blogs", "id": "2", "relationships": {"posts": {"data": [{"type": "posts", "id": "1"}, {"type": "posts", "id": "2"}]}}}
+	]}`
+
+	dec := jsonapi.NewDecoder(strings.NewReader(payload), jsonapi.WithLimits(jsonapi.Limits{
+		MaxRelationshipLinkage: 1,
+	}))
+	var blogs []*Blog
+	e := limitErrorOf(t, dec.Decode(&blogs))
+	if e.Source == nil || e.Source.Pointer != "/data/1/relationships/posts/data" {
+		t.Fatalf("Was expecting the pointer of the second blog's linkage, got %#v", e.Source)
+	}
+}
+
+func TestLimitsDefault(t *testing.T) {
+	body := bytes.NewBufferString(`{"data": {"type": "blogs", "id": "1", "attributes": {"title": "`)
+	body.WriteString(strings.Repeat("a", int(jsonapi.DefaultLimits.MaxBodyBytes)))
+	body.WriteString(`"}}}`)
+
+	e := limitErrorOf(t, jsonapi.UnmarshalPayload(body, new(Blog)))
+	if e.Status != "413" {
+		t.Fatalf("Was expecting status 413, got %q", e.Status)
+	}
+}
+
+func TestLimitsLifted(t *testing.T) {
+	dec := jsonapi.NewDecoder(strings.NewReader(blogWithPosts(3)), jsonapi.WithLimits(jsonapi.Limits{}))
+
+	blog := new(Blog)
+	if err := dec.Decode(blog); err != nil {
+		t.Fatal(err)
+	}
+	if len(blog.Posts) != 3 {
+		t.Fatalf("Was expecting 3 posts, got %d", len(blog.Posts))
+	}
+}
+
+func TestUnmarshalInvalidAttributeType(t *testing.T) {
+	payload := `{"data": {"type": "numeric", "id": "1", "attributes": {"cmplx": 1}}}`
+
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(payload), new(Numeric)); err != jsonapi.ErrInvalidType {
+		t.Fatalf("Was expecting ErrInvalidType, got %v", err)
+	}
+}
+
+func TestUnmarshalUnsupportedTypeError(t *testing.T) {
+	payload := `{"data": {"type": "blogs", "id": "1", "attributes": {"title": true}}}`
+
+	err := jsonapi.UnmarshalPayload(strings.NewReader(payload), new(Blog))
+	if err == nil {
+		t.Fatal("Was expecting an error")
+	}
+	if !strings.Contains(err.Error(), "`Title`") {
+		t.Fatalf("Was expecting the error to name the field, got %q", err.Error())
+	}
+}
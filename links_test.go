@@ -0,0 +1,109 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+const playlistPayload = `{
+	"data": {
+		"type": "playlists",
+		"id": "1",
+		"attributes": {"name": "Mix"},
+		"links": {"self": "/playlists/1"},
+		"meta": {"plays": 12},
+		"relationships": {
+			"tracks": {
+				"data": [{"type": "tracks", "id": "7"}],
+				"links": {"related": {"href": "/playlists/1/tracks", "meta": {"count": 1}}},
+				"meta": {"duration": 180}
+			},
+			"cover": {
+				"data": null,
+				"links": {"self": "/playlists/1/relationships/cover"}
+			}
+		}
+	},
+	"included": [
+		{"type": "tracks", "id": "7", "attributes": {"title": "Intro"}, "links": {"self": "/tracks/7"}}
+	]
+}`
+
+func TestUnmarshalLinksAndMeta(t *testing.T) {
+	playlist := new(Playlist)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(playlistPayload), playlist); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := (&jsonapi.Links{"self": "/playlists/1"}), playlist.JSONAPILinks(); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting the resource links %v, got %v", e, a)
+	}
+	if e, a := (&jsonapi.Meta{"plays": float64(12)}), playlist.JSONAPIMeta(); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting the resource meta %v, got %v", e, a)
+	}
+
+	related, err := playlist.JSONAPIRelationshipLinks("tracks").Link("related")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if related.Href != "/playlists/1/tracks" || related.Meta["count"] != float64(1) {
+		t.Fatalf("Was expecting the tracks related link, got %#v", related)
+	}
+	if e, a := (&jsonapi.Meta{"duration": float64(180)}), playlist.JSONAPIRelationshipMeta("tracks"); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting the tracks meta %v, got %v", e, a)
+	}
+
+	// A relationship without data still hands over its links.
+	if e, a := (&jsonapi.Links{"self": "/playlists/1/relationships/cover"}), playlist.JSONAPIRelationshipLinks("cover"); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting the cover links %v, got %v", e, a)
+	}
+	if playlist.JSONAPIRelationshipMeta("cover") != nil {
+		t.Fatal("Was expecting no cover meta")
+	}
+
+	if e, a := (&jsonapi.Links{"self": "/tracks/7"}), playlist.Tracks[0].JSONAPILinks(); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting the included track links %v, got %v", e, a)
+	}
+}
+
+func TestLinksAndMetaRoundTrip(t *testing.T) {
+	playlist := new(Playlist)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(playlistPayload), playlist); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, playlist); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected, actual map[string]interface{}
+	json.Unmarshal([]byte(playlistPayload), &expected)
+	json.Unmarshal(out.Bytes(), &actual)
+
+	data := actual["data"].(map[string]interface{})
+	expectedData := expected["data"].(map[string]interface{})
+	for _, member := range []string{"links", "meta"} {
+		if !reflect.DeepEqual(expectedData[member], data[member]) {
+			t.Fatalf("Was expecting data.%s %v, got %v", member, expectedData[member], data[member])
+		}
+	}
+
+	tracks := data["relationships"].(map[string]interface{})["tracks"].(map[string]interface{})
+	expectedTracks := expectedData["relationships"].(map[string]interface{})["tracks"].(map[string]interface{})
+	for _, member := range []string{"links", "meta"} {
+		if !reflect.DeepEqual(expectedTracks[member], tracks[member]) {
+			t.Fatalf("Was expecting tracks.%s %v, got %v", member, expectedTracks[member], tracks[member])
+		}
+	}
+
+	included := actual["included"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(included["links"], map[string]interface{}{"self": "/tracks/7"}) {
+		t.Fatalf("Was expecting the included track links, got %v", included["links"])
+	}
+}
@@ -0,0 +1,194 @@
+package jsonapi
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the duration histogram
+// buckets of a Collector created without its own.
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector aggregates instrumentation events into metrics, keyed by the
+// runtime's "instrument" value and the kind of call, and serves them in the
+// Prometheus text exposition format. It keeps a histogram of call durations
+// and counters of failed calls and of payload bytes.
+//
+//	collector := jsonapi.NewCollector()
+//	jsonapi.Instrumentation = collector.Events
+//	http.Handle("/metrics", collector)
+//
+// The Events callback only receives timings; runtimes given Handle as their
+// EventHandler also count errors and payload bytes.
+//
+//	runtime := jsonapi.NewRuntime().Instrument("blogs.list").
+//		WithEventHandler(collector.Handle)
+//
+// A Collector is safe for concurrent use.
+type Collector struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[metricKey]*metricSeries
+}
+
+type metricKey struct {
+	instrument string
+	operation  string
+}
+
+type metricSeries struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+	errors  uint64
+	bytes   int64
+}
+
+// NewCollector creates a Collector with the given histogram bucket upper
+// bounds in seconds, or DefaultBuckets if none are given.
+func NewCollector(buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &Collector{
+		buckets: sorted,
+		series:  make(map[metricKey]*metricSeries),
+	}
+}
+
+// Events records the duration of each call; it can be assigned to
+// Instrumentation.
+func (c *Collector) Events(r *Runtime, e Event, guid string, d time.Duration) {
+	c.Handle(r, EventInfo{Event: e, GUID: guid, Duration: d})
+}
+
+// Handle records the duration, outcome and payload size of each call; it can
+// be given to Runtime.WithEventHandler.
+func (c *Collector) Handle(r *Runtime, info EventInfo) {
+	operation := eventOperation(info.Event)
+	if operation == "" {
+		return
+	}
+
+	instrument, _ := r.Value("instrument").(string)
+	key := metricKey{instrument: instrument, operation: operation}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.series[key]
+	if !ok {
+		s = &metricSeries{buckets: make([]uint64, len(c.buckets))}
+		c.series[key] = s
+	}
+
+	seconds := info.Duration.Seconds()
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.sum += seconds
+	s.bytes += info.Stats.BytesRead + info.Stats.BytesWritten
+	if info.Outcome == OutcomeFailure {
+		s.errors++
+	}
+}
+
+// eventOperation returns the operation label of a stop event, or "" for
+// start events.
+func eventOperation(e Event) string {
+	switch e {
+	case MarshalStop:
+		return "marshal"
+	case UnmarshalStop:
+		return "unmarshal"
+	case MarshalErrorsStop:
+		return "marshal_errors"
+	case UnmarshalErrorsStop:
+		return "unmarshal_errors"
+	}
+
+	return ""
+}
+
+// ServeHTTP writes the collected metrics in the Prometheus text exposition
+// format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metricsContentType)
+
+	out := bufio.NewWriter(w)
+	c.write(out)
+	out.Flush()
+}
+
+func (c *Collector) write(out *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]metricKey, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].instrument != keys[j].instrument {
+			return keys[i].instrument < keys[j].instrument
+		}
+		return keys[i].operation < keys[j].operation
+	})
+
+	fmt.Fprintln(out, "# HELP jsonapi_duration_seconds Duration of jsonapi marshal and unmarshal calls.")
+	fmt.Fprintln(out, "# TYPE jsonapi_duration_seconds histogram")
+	for _, key := range keys {
+		s := c.series[key]
+		labels := key.labels()
+		for i, bound := range c.buckets {
+			fmt.Fprintf(out, "jsonapi_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, formatFloat(bound), s.buckets[i])
+		}
+		fmt.Fprintf(out, "jsonapi_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, s.count)
+		fmt.Fprintf(out, "jsonapi_duration_seconds_sum{%s} %s\n", labels, formatFloat(s.sum))
+		fmt.Fprintf(out, "jsonapi_duration_seconds_count{%s} %d\n", labels, s.count)
+	}
+
+	fmt.Fprintln(out, "# HELP jsonapi_errors_total Number of failed jsonapi calls.")
+	fmt.Fprintln(out, "# TYPE jsonapi_errors_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(out, "jsonapi_errors_total{%s} %d\n", key.labels(), c.series[key].errors)
+	}
+
+	fmt.Fprintln(out, "# HELP jsonapi_payload_bytes_total Number of payload bytes read and written by jsonapi calls.")
+	fmt.Fprintln(out, "# TYPE jsonapi_payload_bytes_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(out, "jsonapi_payload_bytes_total{%s} %d\n", key.labels(), c.series[key].bytes)
+	}
+}
+
+func (k metricKey) labels() string {
+	return fmt.Sprintf("instrument=\"%s\",operation=\"%s\"",
+		escapeLabelValue(k.instrument), escapeLabelValue(k.operation))
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,57 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestCollector(t *testing.T) {
+	collector := jsonapi.NewCollector(0.01, 1)
+	runtime := jsonapi.NewRuntime().Instrument("blogs.show").WithEventHandler(collector.Handle)
+
+	out := bytes.NewBuffer(nil)
+	if err := runtime.MarshalPayload(out, testBlog()); err != nil {
+		t.Fatal(err)
+	}
+	written := out.Len()
+	if err := runtime.UnmarshalPayload(strings.NewReader("{"), new(Blog)); err == nil {
+		t.Fatal("Was expecting an error")
+	}
+
+	// Events only receives timings.
+	collector.Events(jsonapi.NewRuntime().Instrument(`say "hi"`), jsonapi.MarshalStop, "guid", 2*time.Second)
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if e, a := "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"); e != a {
+		t.Fatalf("Was expecting content type `%s`, got `%s`", e, a)
+	}
+
+	body, _ := ioutil.ReadAll(rec.Body)
+	metrics := string(body)
+
+	for _, line := range []string{
+		"# TYPE jsonapi_duration_seconds histogram",
+		`jsonapi_duration_seconds_bucket{instrument="blogs.show",operation="marshal",le="+Inf"} 1`,
+		`jsonapi_duration_seconds_count{instrument="blogs.show",operation="unmarshal"} 1`,
+		`jsonapi_duration_seconds_bucket{instrument="say \"hi\"",operation="marshal",le="1"} 0`,
+		`jsonapi_duration_seconds_sum{instrument="say \"hi\"",operation="marshal"} 2`,
+		"# TYPE jsonapi_errors_total counter",
+		`jsonapi_errors_total{instrument="blogs.show",operation="marshal"} 0`,
+		`jsonapi_errors_total{instrument="blogs.show",operation="unmarshal"} 1`,
+		"# TYPE jsonapi_payload_bytes_total counter",
+		`jsonapi_payload_bytes_total{instrument="blogs.show",operation="marshal"} ` + strconv.Itoa(written),
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Fatalf("Was expecting metrics to contain %q, got:\n%s", line, metrics)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package jsonapi_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cheeryfella/jsonapi"
@@ -221,3 +225,222 @@ type JSONAPITypes struct {
 	//SI []int `jsonapi:"attr,si"`
 	//b bool `jsonapi:"attr,b"`
 }
+
+type Author struct {
+	ID     string   `jsonapi:"primary,authors"`
+	Name   string   `jsonapi:"attr,name,required,min=2,maxlen=20"`
+	Email  string   `jsonapi:"attr,email,format=email"`
+	Age    *int     `jsonapi:"attr,age,min=18,max=130"`
+	Role   string   `jsonapi:"attr,role,omitempty,enum=admin|editor"`
+	Tags   []string `jsonapi:"attr,tags,max=2"`
+	Editor *Author  `jsonapi:"relation,editor"`
+}
+
+func (a *Author) JSONAPIValidate() error {
+	if a.Role == "admin" && a.Email == "" {
+		return &jsonapi.ErrorObject{
+			Title:  "Missing Email",
+			Detail: "admins must have an email",
+			Source: &jsonapi.ErrorSource{Pointer: "/attributes/email"},
+		}
+	}
+	return nil
+}
+
+type Article struct {
+	ID      string  `jsonapi:"primary,articles"`
+	Body    string  `jsonapi:"attr,body"`
+	Excerpt string  `jsonapi:"attr,excerpt,omitempty"`
+	Writer  *Writer `jsonapi:"relation,writer"`
+}
+
+func (a *Article) BeforeMarshal(ctx context.Context) error {
+	a.Excerpt = a.Body
+	if len(a.Excerpt) > 10 {
+		a.Excerpt = a.Excerpt[:10]
+	}
+	return nil
+}
+
+type Writer struct {
+	ID    string `jsonapi:"primary,writers"`
+	Email string `jsonapi:"attr,email"`
+	Note  string
+}
+
+var errWriterEmail = errors.New("writers must have an email")
+
+// noteKey is the context key of a note that Writer hooks copy to Note.
+type noteKey struct{}
+
+func (w *Writer) BeforeMarshal(ctx context.Context) error {
+	if w.Email == "" {
+		return errWriterEmail
+	}
+	if note, ok := ctx.Value(noteKey{}).(string); ok {
+		w.Note = note
+	}
+	return nil
+}
+
+func (w *Writer) AfterUnmarshal(ctx context.Context) error {
+	w.Email = strings.ToLower(strings.TrimSpace(w.Email))
+	if w.Email == "" {
+		return errWriterEmail
+	}
+	if note, ok := ctx.Value(noteKey{}).(string); ok {
+		w.Note = note
+	}
+	return nil
+}
+
+type Schedule struct {
+	ID        int          `jsonapi:"primary,schedules"`
+	Starts    time.Time    `jsonapi:"attr,starts,rfc3339"`
+	Precise   time.Time    `jsonapi:"attr,precise,rfc3339nano"`
+	Millis    *time.Time   `jsonapi:"attr,millis,unixmilli"`
+	Day       time.Time    `jsonapi:"attr,day,date"`
+	Custom    time.Time    `jsonapi:"attr,custom,layout=02/01/2006 15:04"`
+	Holidays  []time.Time  `jsonapi:"attr,holidays,date"`
+	Reminders []*time.Time `jsonapi:"attr,reminders,iso8601"`
+}
+
+type Inventory struct {
+	ID       string                `jsonapi:"primary,inventories"`
+	Counts   map[string]int        `jsonapi:"attr,counts"`
+	Readings map[string][]float64  `jsonapi:"attr,readings"`
+	Labels   map[int]string        `jsonapi:"attr,labels"`
+	Sizes    map[string]*Dimension `jsonapi:"attr,sizes"`
+	Tags     []*string             `jsonapi:"attr,tags"`
+	Grid     [2][2]int             `jsonapi:"attr,grid"`
+	Extra    interface{}           `jsonapi:"attr,extra"`
+	Raw      json.RawMessage       `jsonapi:"attr,raw"`
+	Blob     []byte                `jsonapi:"attr,blob"`
+	Items    []*Item               `jsonapi:"attr,items"`
+	Origin   *Location             `jsonapi:"attr,origin"`
+}
+
+// Location has no jsonapi tags, so it is encoded using its json tags.
+type Location struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lng"`
+}
+
+type Dimension struct {
+	Width  int `jsonapi:"attr,width"`
+	Height int `jsonapi:"attr,height,omitempty"`
+}
+
+type Item struct {
+	SKU      string `jsonapi:"attr,sku"`
+	Quantity int    `jsonapi:"attr,quantity"`
+}
+
+// Playlist keeps the links and meta it is unmarshalled with, and marshals
+// them back.
+type Playlist struct {
+	ID     string   `jsonapi:"primary,playlists"`
+	Name   string   `jsonapi:"attr,name"`
+	Tracks []*Track `jsonapi:"relation,tracks"`
+	Cover  *Track   `jsonapi:"relation,cover"`
+
+	links             *jsonapi.Links
+	meta              *jsonapi.Meta
+	relationshipLinks map[string]*jsonapi.Links
+	relationshipMeta  map[string]*jsonapi.Meta
+}
+
+func (p *Playlist) JSONAPILinks() *jsonapi.Links     { return p.links }
+func (p *Playlist) SetJSONAPILinks(l *jsonapi.Links) { p.links = l }
+func (p *Playlist) JSONAPIMeta() *jsonapi.Meta       { return p.meta }
+func (p *Playlist) SetJSONAPIMeta(m *jsonapi.Meta)   { p.meta = m }
+
+func (p *Playlist) JSONAPIRelationshipLinks(relation string) *jsonapi.Links {
+	return p.relationshipLinks[relation]
+}
+
+func (p *Playlist) SetJSONAPIRelationshipLinks(relation string, l *jsonapi.Links) {
+	if p.relationshipLinks == nil {
+		p.relationshipLinks = make(map[string]*jsonapi.Links)
+	}
+	p.relationshipLinks[relation] = l
+}
+
+func (p *Playlist) JSONAPIRelationshipMeta(relation string) *jsonapi.Meta {
+	return p.relationshipMeta[relation]
+}
+
+func (p *Playlist) SetJSONAPIRelationshipMeta(relation string, m *jsonapi.Meta) {
+	if p.relationshipMeta == nil {
+		p.relationshipMeta = make(map[string]*jsonapi.Meta)
+	}
+	p.relationshipMeta[relation] = m
+}
+
+type Track struct {
+	ID    string `jsonapi:"primary,tracks"`
+	Title string `jsonapi:"attr,title"`
+
+	links *jsonapi.Links
+}
+
+func (t *Track) JSONAPILinks() *jsonapi.Links     { return t.links }
+func (t *Track) SetJSONAPILinks(l *jsonapi.Links) { t.links = l }
+
+// Story and Person reference each other, so their graphs can have cycles.
+type Story struct {
+	ID     string  `jsonapi:"primary,stories"`
+	Title  string  `jsonapi:"attr,title"`
+	Author *Person `jsonapi:"relation,author"`
+}
+
+type Person struct {
+	ID      string   `jsonapi:"primary,people"`
+	Name    string   `jsonapi:"attr,name"`
+	Stories []*Story `jsonapi:"relation,stories"`
+	Friend  *Person  `jsonapi:"relation,friend"`
+}
+
+// Models with tags a Registry rejects.
+type BadMemberName struct {
+	ID    string `jsonapi:"primary,bad-members"`
+	Title string `jsonapi:"attr,-title"`
+}
+
+// CommaLayout has a time layout that the tag spec splits at its comma.
+type CommaLayout struct {
+	ID   string    `jsonapi:"primary,comma-layouts"`
+	Date time.Time `jsonapi:"attr,date,layout=Jan 2, 2006,omitempty"`
+}
+
+type BadAttributeOption struct {
+	ID    string `jsonapi:"primary,bad-options"`
+	Title string `jsonapi:"attr,title,max=ten"`
+}
+
+type DuplicateMember struct {
+	ID       string  `jsonapi:"primary,duplicates"`
+	Title    string  `jsonapi:"attr,title"`
+	Headline string  `jsonapi:"attr,title"`
+	Owner    *Person `jsonapi:"relation,owner"`
+}
+
+type ReservedMember struct {
+	ID   string `jsonapi:"primary,reserved"`
+	Kind string `jsonapi:"attr,type"`
+}
+
+type BadRelation struct {
+	ID     string    `jsonapi:"primary,bad-relations"`
+	Author *Person   `jsonapi:"relation,author"`
+	Note   *BadModel `jsonapi:"relation,note"`
+}
+
+type FloatID struct {
+	ID float64 `jsonapi:"primary,floats"`
+}
+
+// OtherStory claims the resource type of Story.
+type OtherStory struct {
+	ID string `jsonapi:"primary,stories"`
+}
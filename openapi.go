@@ -0,0 +1,587 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification that the
+// documents generated by Registry.OpenAPI follow.
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPIInfo is the "info" object of a generated OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// pageParams are the page query parameters. Those that are numbers have a
+// minimum; the cursor doesn't.
+var pageParams = []struct {
+	name    string
+	minimum int
+}{
+	{QueryParamPageNumber, 1},
+	{QueryParamPageSize, 1},
+	{QueryParamPageOffset, 0},
+	{QueryParamPageLimit, 1},
+	{QueryParamPageCursor, -1},
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the registered models,
+// ready to be marshalled with encoding/json. Its "components" hold, for
+// each resource type:
+//
+//   - "<type>.Resource", the resource object;
+//   - "<type>.Identifier", the resource identifier object;
+//   - "<type>.Document" and "<type>.CollectionDocument", the documents with
+//     a single resource and with many as primary data;
+//   - "<type>.<relationship>.Relationship", the relationship object, which is
+//     also the document of the relationship's own URL;
+//   - "fields.<type>", the sparse fieldsets query parameter;
+//
+// along with the "ErrorsDocument" schema and the "include", "sort" and
+// "page.<name>" query parameters. Its "paths" are left for the caller to
+// fill, referring to these components.
+func (r *Registry) OpenAPI(info OpenAPIInfo) map[string]interface{} {
+	schemas := r.Schemas()
+
+	components := map[string]interface{}{
+		"Link":           openAPILink(),
+		"Links":          openAPILinks(),
+		"Meta":           map[string]interface{}{"type": "object", "additionalProperties": true},
+		"JSONAPI":        openAPIJSONAPI(),
+		"Error":          openAPIError(),
+		"ErrorsDocument": openAPIErrorsDocument(),
+	}
+	parameters := openAPIParameters()
+
+	for _, schema := range schemas {
+		name := openAPIName(schema.Type)
+
+		components[name+".Resource"] = openAPIResource(schema)
+		components[name+".Identifier"] = openAPIIdentifier(schema.Type)
+
+		included := r.related(schema)
+		components[name+".Document"] = openAPIDocument(openAPIRef(name+".Resource"), included)
+		components[name+".CollectionDocument"] = openAPIDocument(map[string]interface{}{
+			"type":  "array",
+			"items": openAPIRef(name + ".Resource"),
+		}, included)
+
+		for _, relationship := range schema.Relationships {
+			components[name+"."+openAPIName(relationship.Name)+".Relationship"] = openAPIRelationship(relationship)
+		}
+
+		parameters["fields."+name] = openAPIFields(schema)
+	}
+
+	return map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info":    info,
+		"paths":   map[string]interface{}{},
+		"components": map[string]interface{}{
+			"schemas":    components,
+			"parameters": parameters,
+		},
+	}
+}
+
+// WriteOpenAPI writes the OpenAPI document of the registered models to w as
+// indented JSON.
+func (r *Registry) WriteOpenAPI(w io.Writer, info OpenAPIInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r.OpenAPI(info))
+}
+
+// related returns the registered resource types reachable from schema
+// through relationships, sorted, which may appear in "included".
+func (r *Registry) related(schema *Schema) []string {
+	seen := map[string]bool{}
+	queue := []*Schema{schema}
+
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+
+		for _, relationship := range s.Relationships {
+			if seen[relationship.Type] {
+				continue
+			}
+			seen[relationship.Type] = true
+
+			if related, ok := r.Schema(relationship.Type); ok {
+				queue = append(queue, related)
+			}
+		}
+	}
+
+	types := make([]string, 0, len(seen))
+	for t := range seen {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// openAPIName returns name with the characters that OpenAPI component names
+// don't allow replaced by underscores.
+func openAPIName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func openAPIRef(component string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + component}
+}
+
+func openAPIResource(schema *Schema) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	var required []string
+	for _, attribute := range schema.Attributes {
+		attributes[attribute.Name] = openAPIAttribute(attribute)
+		if _, ok := attribute.Option(annotationRequired); ok {
+			required = append(required, attribute.Name)
+		}
+	}
+
+	attributesSchema := map[string]interface{}{
+		"type":       "object",
+		"properties": attributes,
+	}
+	if len(required) > 0 {
+		attributesSchema["required"] = required
+	}
+
+	relationships := map[string]interface{}{}
+	for _, relationship := range schema.Relationships {
+		relationships[relationship.Name] = openAPIRef(
+			openAPIName(schema.Type) + "." + openAPIName(relationship.Name) + ".Relationship")
+	}
+
+	properties := map[string]interface{}{
+		"type":       map[string]interface{}{"type": "string", "enum": []string{schema.Type}},
+		"id":         map[string]interface{}{"type": "string"},
+		"attributes": attributesSchema,
+		"links":      openAPIRef("Links"),
+		"meta":       openAPIRef("Meta"),
+	}
+	if len(relationships) > 0 {
+		properties["relationships"] = map[string]interface{}{
+			"type":       "object",
+			"properties": relationships,
+		}
+	}
+
+	// The id is absent from resources created with a server generated ID.
+	return map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"type"},
+		"properties": properties,
+	}
+}
+
+func openAPIIdentifier(resourceType string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"type", "id"},
+		"properties": map[string]interface{}{
+			"type": map[string]interface{}{"type": "string", "enum": []string{resourceType}},
+			"id":   map[string]interface{}{"type": "string"},
+			"meta": openAPIRef("Meta"),
+		},
+	}
+}
+
+func openAPIRelationship(relationship RelationshipSchema) map[string]interface{} {
+	identifier := openAPIRef(openAPIName(relationship.Type) + ".Identifier")
+
+	var data map[string]interface{}
+	if relationship.Cardinality == ToMany {
+		data = map[string]interface{}{"type": "array", "items": identifier}
+	} else {
+		data = map[string]interface{}{
+			"nullable": true,
+			"allOf":    []interface{}{identifier},
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"data":  data,
+			"links": openAPIRef("Links"),
+			"meta":  openAPIRef("Meta"),
+		},
+	}
+}
+
+func openAPIDocument(data map[string]interface{}, included []string) map[string]interface{} {
+	properties := map[string]interface{}{
+		"data":    data,
+		"links":   openAPIRef("Links"),
+		"meta":    openAPIRef("Meta"),
+		"jsonapi": openAPIRef("JSONAPI"),
+	}
+
+	if len(included) > 0 {
+		resources := make([]interface{}, len(included))
+		for i, t := range included {
+			resources[i] = openAPIRef(openAPIName(t) + ".Resource")
+		}
+		properties["included"] = map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"oneOf": resources},
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"data"},
+		"properties": properties,
+	}
+}
+
+// openAPIAttribute returns the schema of an attribute, with the constraints
+// of its validation tag options.
+func openAPIAttribute(attribute AttributeSchema) map[string]interface{} {
+	args := append([]string{annotationAttribute, attribute.Name}, attribute.Options...)
+	s := openAPIValue(attribute.GoType, args, map[reflect.Type]bool{})
+
+	t := attribute.GoType
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, option := range attribute.Options {
+		rule, param := option, ""
+		if idx := strings.Index(option, annotationValueSeperator); idx >= 0 {
+			rule, param = option[:idx], option[idx+1:]
+		}
+
+		switch rule {
+		case annotationMin, annotationMax:
+			bound, _ := strconv.ParseFloat(param, 64)
+			min, max := openAPIBounds(t.Kind())
+			if rule == annotationMin && min != "" {
+				s[min] = bound
+			} else if rule == annotationMax && max != "" {
+				s[max] = bound
+			}
+		case annotationMaxLen:
+			// maxlen only bounds lengths, not numbers.
+			bound, _ := strconv.Atoi(param)
+			if _, max := openAPIBounds(t.Kind()); max != "" && max != "maximum" {
+				s[max] = bound
+			}
+		case annotationEnum:
+			var values []interface{}
+			for _, value := range strings.Split(param, annotationEnumSeperator) {
+				values = append(values, openAPIEnumValue(t.Kind(), value))
+			}
+			s["enum"] = values
+		case annotationFormat:
+			s["format"] = param
+		}
+	}
+
+	return s
+}
+
+// openAPIBounds returns the keywords that bound a value of kind, as the min
+// and max tag options do: a number's value, or a length.
+func openAPIBounds(kind reflect.Kind) (min, max string) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "minimum", "maximum"
+	case reflect.String:
+		return "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		return "minItems", "maxItems"
+	case reflect.Map:
+		return "minProperties", "maxProperties"
+	}
+
+	return "", ""
+}
+
+func openAPIEnumValue(kind reflect.Kind, value string) interface{} {
+	switch kind {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
+
+// openAPIValue returns the schema of the values of type t as they are
+// written to the "attributes" hash. args are the attr tag arguments, which
+// select the format of times. seen holds the structs being described, so
+// recursive types end.
+func openAPIValue(t reflect.Type, args []string, seen map[reflect.Type]bool) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		s := openAPIValue(t.Elem(), args, seen)
+		s["nullable"] = true
+		return s
+	}
+
+	// The output of codecs and json.Marshalers is unknown.
+	if hasEncoder(t) {
+		return map[string]interface{}{}
+	}
+
+	if t.ConvertibleTo(timeType) {
+		switch option, _, _ := timeFormat(args); option {
+		case annotationISO8601, annotationRFC3339, annotationRFC3339Nano:
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		case annotationDate:
+			return map[string]interface{}{"type": "string", "format": "date"}
+		case annotationLayout:
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	}
+
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return map[string]interface{}{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": openAPIValue(t.Elem(), args, seen)}
+	case reflect.Array:
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    openAPIValue(t.Elem(), args, seen),
+			"minItems": t.Len(),
+			"maxItems": t.Len(),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": openAPIValue(t.Elem(), args, seen),
+		}
+	case reflect.Struct:
+		if !hasAttributeTags(t) || seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			fieldArgs := strings.Split(field.Tag.Get(annotationJSONAPI), annotationSeperator)
+			if field.PkgPath != "" || len(fieldArgs) < 2 || fieldArgs[0] != annotationAttribute {
+				continue
+			}
+			properties[fieldArgs[1]] = openAPIValue(field.Type, fieldArgs, seen)
+		}
+
+		return map[string]interface{}{"type": "object", "properties": properties}
+	}
+
+	// Interfaces, and anything else encoding/json makes of a value.
+	return map[string]interface{}{}
+}
+
+func openAPILink() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"href"},
+		"properties": map[string]interface{}{
+			"href": map[string]interface{}{"type": "string", "format": "uri-reference"},
+			"meta": openAPIRef("Meta"),
+		},
+	}
+}
+
+func openAPILinks() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"nullable": true,
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string", "format": "uri-reference"},
+				openAPIRef("Link"),
+			},
+		},
+	}
+}
+
+func openAPIJSONAPI() map[string]interface{} {
+	uris := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string", "format": "uri"},
+	}
+
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"version": map[string]interface{}{"type": "string"},
+			"ext":     uris,
+			"profile": uris,
+			"meta":    openAPIRef("Meta"),
+		},
+	}
+}
+
+// openAPIError returns the schema of an ErrorObject.
+func openAPIError() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	link := map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string", "format": "uri-reference"},
+			openAPIRef("Link"),
+		},
+	}
+
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": str,
+			"links": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"about": link,
+					"type":  link,
+				},
+			},
+			"status": str,
+			"code":   str,
+			"title":  str,
+			"detail": str,
+			"source": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pointer":   str,
+					"parameter": str,
+					"header":    str,
+				},
+			},
+			"meta": openAPIRef("Meta"),
+		},
+	}
+}
+
+// openAPIErrorsDocument returns the schema of an ErrorsPayload.
+func openAPIErrorsDocument() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"errors"},
+		"properties": map[string]interface{}{
+			"errors": map[string]interface{}{
+				"type":  "array",
+				"items": openAPIRef("Error"),
+			},
+			"links":   openAPIRef("Links"),
+			"meta":    openAPIRef("Meta"),
+			"jsonapi": openAPIRef("JSONAPI"),
+		},
+	}
+}
+
+// openAPIList returns a query parameter holding a comma separated list.
+func openAPIList(name, description string, items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"in":          "query",
+		"description": description,
+		"style":       "form",
+		"explode":     false,
+		"schema":      map[string]interface{}{"type": "array", "items": items},
+	}
+}
+
+// openAPIParameters returns the query parameters that don't depend on the
+// registered models, keyed by component name.
+func openAPIParameters() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+
+	parameters := map[string]interface{}{
+		"include": openAPIList("include",
+			"The relationship paths of the related resources to include.", str),
+		"sort": openAPIList("sort",
+			"The fields to sort by, each descending if prefixed with a minus.", str),
+	}
+
+	for _, param := range pageParams {
+		schema := str
+		if param.minimum >= 0 {
+			schema = map[string]interface{}{"type": "integer", "minimum": param.minimum}
+		}
+
+		key := strings.Replace(strings.Replace(param.name, "[", ".", 1), "]", "", 1)
+		parameters[key] = map[string]interface{}{
+			"name":   param.name,
+			"in":     "query",
+			"schema": schema,
+		}
+	}
+
+	return parameters
+}
+
+// openAPIFields returns the sparse fieldsets query parameter of a resource
+// type.
+func openAPIFields(schema *Schema) map[string]interface{} {
+	var fields []string
+	for _, attribute := range schema.Attributes {
+		fields = append(fields, attribute.Name)
+	}
+	for _, relationship := range schema.Relationships {
+		fields = append(fields, relationship.Name)
+	}
+
+	items := map[string]interface{}{"type": "string"}
+	if len(fields) > 0 {
+		items["enum"] = fields
+	}
+
+	return openAPIList("fields["+schema.Type+"]",
+		"The fields of "+schema.Type+" to return.", items)
+}
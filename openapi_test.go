@@ -0,0 +1,225 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+// openAPIDocument generates the OpenAPI document of models and decodes it
+// back, as a client of the generated JSON would see it.
+func openAPIDocument(t *testing.T, models ...interface{}) map[string]interface{} {
+	t.Helper()
+
+	registry := jsonapi.NewRegistry()
+	if err := registry.Register(models...); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := registry.WriteOpenAPI(out, jsonapi.OpenAPIInfo{Title: "Blogs", Version: "1.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+// lookup follows a path of object members from v, failing if one is missing.
+func lookup(t *testing.T, v interface{}, path ...string) interface{} {
+	t.Helper()
+
+	for i, name := range path {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			t.Fatalf("Was expecting an object at %s", strings.Join(path[:i], "/"))
+		}
+		if v, ok = object[name]; !ok {
+			t.Fatalf("Was expecting a member at %s", strings.Join(path[:i+1], "/"))
+		}
+	}
+
+	return v
+}
+
+func TestOpenAPIResources(t *testing.T) {
+	doc := openAPIDocument(t, new(Blog))
+
+	if doc["openapi"] != jsonapi.OpenAPIVersion {
+		t.Fatalf("Was expecting version %s, got %v", jsonapi.OpenAPIVersion, doc["openapi"])
+	}
+	if title := lookup(t, doc, "info", "title"); title != "Blogs" {
+		t.Fatalf("Was expecting the info title, got %v", title)
+	}
+
+	schemas := lookup(t, doc, "components", "schemas")
+	attributes := lookup(t, schemas, "blogs.Resource", "properties", "attributes", "properties")
+
+	tests := map[string]map[string]interface{}{
+		"title":      {"type": "string"},
+		"view_count": {"type": "integer", "format": "int64"},
+		"created_at": {"type": "integer", "format": "int64"},
+	}
+	for name, expected := range tests {
+		if actual := lookup(t, attributes, name); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("Was expecting %s to be %v, got %v", name, expected, actual)
+		}
+	}
+
+	resourceType := lookup(t, schemas, "blogs.Resource", "properties", "type", "enum")
+	if !reflect.DeepEqual(resourceType, []interface{}{"blogs"}) {
+		t.Fatalf("Was expecting the type to be blogs, got %v", resourceType)
+	}
+
+	posts := lookup(t, schemas, "blogs.Resource", "properties", "relationships", "properties", "posts", "$ref")
+	if posts != "#/components/schemas/blogs.posts.Relationship" {
+		t.Fatalf("Was expecting a reference to the posts relationship, got %v", posts)
+	}
+
+	items := lookup(t, schemas, "blogs.CollectionDocument", "properties", "data", "items", "$ref")
+	if items != "#/components/schemas/blogs.Resource" {
+		t.Fatalf("Was expecting the collection of blogs, got %v", items)
+	}
+
+	included := lookup(t, schemas, "blogs.Document", "properties", "included", "items", "oneOf")
+	expected := []interface{}{
+		map[string]interface{}{"$ref": "#/components/schemas/comments.Resource"},
+		map[string]interface{}{"$ref": "#/components/schemas/posts.Resource"},
+	}
+	if !reflect.DeepEqual(included, expected) {
+		t.Fatalf("Was expecting comments and posts to be included, got %v", included)
+	}
+}
+
+func TestOpenAPIRelationships(t *testing.T) {
+	schemas := lookup(t, openAPIDocument(t, new(Post)), "components", "schemas")
+
+	toMany := lookup(t, schemas, "posts.comments.Relationship", "properties", "data")
+	expected := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"$ref": "#/components/schemas/comments.Identifier"},
+	}
+	if !reflect.DeepEqual(toMany, expected) {
+		t.Fatalf("Was expecting an array of comment identifiers, got %v", toMany)
+	}
+
+	toOne := lookup(t, schemas, "posts.latest_comment.Relationship", "properties", "data")
+	if lookup(t, toOne, "nullable") != true {
+		t.Fatalf("Was expecting a nullable to-one linkage, got %v", toOne)
+	}
+
+	required := lookup(t, schemas, "comments.Identifier", "required")
+	if !reflect.DeepEqual(required, []interface{}{"type", "id"}) {
+		t.Fatalf("Was expecting type and id to be required, got %v", required)
+	}
+}
+
+func TestOpenAPIAttributeConstraints(t *testing.T) {
+	schemas := lookup(t, openAPIDocument(t, new(Author), new(Timestamp), new(Inventory)), "components", "schemas")
+	attributes := lookup(t, schemas, "authors.Resource", "properties", "attributes")
+
+	if required := lookup(t, attributes, "required"); !reflect.DeepEqual(required, []interface{}{"name"}) {
+		t.Fatalf("Was expecting name to be required, got %v", required)
+	}
+
+	tests := map[string]map[string]interface{}{
+		"name":  {"type": "string", "minLength": 2.0, "maxLength": 20.0},
+		"email": {"type": "string", "format": "email"},
+		"age":   {"type": "integer", "format": "int64", "nullable": true, "minimum": 18.0, "maximum": 130.0},
+		"role":  {"type": "string", "enum": []interface{}{"admin", "editor"}},
+		"tags":  {"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 2.0},
+	}
+	for name, expected := range tests {
+		if actual := lookup(t, attributes, "properties", name); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("Was expecting %s to be %v, got %v", name, expected, actual)
+		}
+	}
+
+	next := lookup(t, schemas, "timestamps.Resource", "properties", "attributes", "properties", "next")
+	expected := map[string]interface{}{"type": "string", "format": "date-time", "nullable": true}
+	if !reflect.DeepEqual(next, expected) {
+		t.Fatalf("Was expecting an ISO 8601 time, got %v", next)
+	}
+
+	inventory := lookup(t, schemas, "inventories.Resource", "properties", "attributes", "properties")
+	if blob := lookup(t, inventory, "blob", "format"); blob != "byte" {
+		t.Fatalf("Was expecting bytes as base64, got %v", blob)
+	}
+	if grid := lookup(t, inventory, "grid", "maxItems"); grid != 2.0 {
+		t.Fatalf("Was expecting an array of 2, got %v", grid)
+	}
+	if counts := lookup(t, inventory, "counts", "additionalProperties", "type"); counts != "integer" {
+		t.Fatalf("Was expecting a map of integers, got %v", counts)
+	}
+	if items := lookup(t, inventory, "items", "items", "properties"); len(items.(map[string]interface{})) == 0 {
+		t.Fatal("Was expecting the properties of the nested items")
+	}
+}
+
+func TestOpenAPIErrorsAndParameters(t *testing.T) {
+	doc := openAPIDocument(t, new(Blog))
+
+	errors := lookup(t, doc, "components", "schemas", "ErrorsDocument", "properties", "errors", "items", "$ref")
+	if errors != "#/components/schemas/Error" {
+		t.Fatalf("Was expecting an array of errors, got %v", errors)
+	}
+	lookup(t, doc, "components", "schemas", "Error", "properties", "source", "properties", "pointer")
+
+	parameters := lookup(t, doc, "components", "parameters")
+	names := map[string]string{
+		"include":      "include",
+		"sort":         "sort",
+		"page.number":  jsonapi.QueryParamPageNumber,
+		"page.size":    jsonapi.QueryParamPageSize,
+		"page.offset":  jsonapi.QueryParamPageOffset,
+		"page.limit":   jsonapi.QueryParamPageLimit,
+		"page.cursor":  jsonapi.QueryParamPageCursor,
+		"fields.blogs": "fields[blogs]",
+	}
+	for key, name := range names {
+		if actual := lookup(t, parameters, key, "name"); actual != name {
+			t.Fatalf("Was expecting parameter %s to be named %s, got %v", key, name, actual)
+		}
+	}
+
+	fields := lookup(t, parameters, "fields.posts", "schema", "items", "enum")
+	expected := []interface{}{"blog_id", "title", "body", "comments", "latest_comment"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("Was expecting the fields of posts, got %v", fields)
+	}
+}
+
+// TestOpenAPIReferences checks that every reference resolves to a component.
+func TestOpenAPIReferences(t *testing.T) {
+	doc := openAPIDocument(t, new(Blog), new(Story), new(Author), new(Playlist))
+	schemas := lookup(t, doc, "components", "schemas").(map[string]interface{})
+
+	var visit func(v interface{})
+	visit = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok {
+					t.Fatalf("Was expecting %s to resolve", ref)
+				}
+			}
+			for _, child := range v {
+				visit(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				visit(child)
+			}
+		}
+	}
+	visit(doc)
+}
@@ -0,0 +1,264 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// IncludeMode selects how an Encoder serializes related resources.
+type IncludeMode int
+
+const (
+	// IncludeSideloaded writes related resources into the top level
+	// "included" array, leaving resource linkage in the relationships. This
+	// is the default, and the behaviour of MarshalPayload.
+	IncludeSideloaded IncludeMode = iota
+
+	// IncludeNone writes resource linkage only, without an "included" array,
+	// as MarshalPayloadWithoutIncluded does.
+	IncludeNone
+
+	// IncludeEmbedded writes related resources inline in their
+	// relationships, as MarshalOnePayloadEmbedded does.
+	IncludeEmbedded
+)
+
+// Option configures an Encoder or a Decoder. Options that only apply in one
+// direction are ignored by the other.
+type Option func(*options)
+
+type options struct {
+	ctx         context.Context
+	includeMode IncludeMode
+	prefix      string
+	indent      string
+	escapeHTML  bool
+	strict      bool
+	links       *Links
+	meta        *Meta
+	maxDepth    int
+	stats       *Stats
+	tracer      Tracer
+	limits      Limits
+	checks      bool
+
+	// partial makes the primary resource a partial update, whose required
+	// attributes may be absent.
+	partial bool
+	// deferChecks leaves the AfterUnmarshal hook and the Validator of the
+	// primary resource to be run on the model it is applied onto.
+	deferChecks bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		ctx:        context.Background(),
+		escapeHTML: true,
+		tracer:     NoopTracer{},
+		limits:     DefaultLimits,
+		checks:     true,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithContext sets the context passed to BeforeMarshal and AfterUnmarshal
+// hooks. The default is context.Background().
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithIncludeMode sets how an Encoder serializes related resources.
+func WithIncludeMode(mode IncludeMode) Option {
+	return func(o *options) {
+		o.includeMode = mode
+	}
+}
+
+// WithIndent makes an Encoder indent its output, as json.Encoder.SetIndent.
+func WithIndent(prefix, indent string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+		o.indent = indent
+	}
+}
+
+// WithEscapeHTML sets whether an Encoder escapes <, > and & in strings, as
+// json.Encoder.SetEscapeHTML. Escaping is on by default.
+func WithEscapeHTML(on bool) Option {
+	return func(o *options) {
+		o.escapeHTML = on
+	}
+}
+
+// WithStrict makes a Decoder reject resources with attributes or
+// relationships that their model doesn't declare, returning
+// ErrUnknownMember.
+func WithStrict(strict bool) Option {
+	return func(o *options) {
+		o.strict = strict
+	}
+}
+
+// WithLinks sets the top level "links" of the documents written by an
+// Encoder, replacing those of a Linkable slice.
+func WithLinks(links *Links) Option {
+	return func(o *options) {
+		o.links = links
+	}
+}
+
+// WithMeta sets the top level "meta" of the documents written by an Encoder,
+// replacing that of a Metable slice.
+func WithMeta(meta *Meta) Option {
+	return func(o *options) {
+		o.meta = meta
+	}
+}
+
+// WithMaxDepth limits how many levels of relationships are followed from the
+// primary data. An Encoder writes resources deeper than depth as resource
+// linkage only, without including them; a Decoder doesn't resolve them from
+// "included", so their models have only their ID set. Zero, the default,
+// means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = depth
+	}
+}
+
+// WithChecks turns the AfterUnmarshal hooks and the validation of decoded
+// models on or off. They are on by default; a client decoding a server's
+// response may turn them off.
+func WithChecks(on bool) Option {
+	return func(o *options) {
+		o.checks = on
+	}
+}
+
+// withStats makes an Encoder or Decoder record the statistics of its calls
+// into stats. It is used by Runtime to instrument calls.
+func withStats(stats *Stats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
+// withPartial makes a Decoder read the primary resource as a partial
+// update, whose required attributes may be absent. It is used by the PATCH
+// helpers.
+func withPartial() Option {
+	return func(o *options) {
+		o.partial = true
+	}
+}
+
+// withDeferredChecks makes a Decoder skip the AfterUnmarshal hook and the
+// Validator of a partial primary resource, leaving them to the caller.
+func withDeferredChecks() Option {
+	return func(o *options) {
+		o.deferChecks = true
+	}
+}
+
+// walk carries the options and bookkeeping of a single marshal or unmarshal
+// call through the recursive visit of a model graph.
+type walk struct {
+	ctx   context.Context
+	opts  *options
+	depth int
+	stats *Stats
+
+	// nodes holds the resource objects built by a marshal call, so each
+	// model is visited once and cyclic graphs end.
+	nodes map[nodeKey]*visitedNode
+	// models holds the models created by an unmarshal call by type and ID,
+	// so each resource becomes a single model and cyclic graphs end.
+	models map[string]*unmarshalledModel
+	// noHooks skips the BeforeMarshal hooks of the models visited, which are
+	// then only read.
+	noHooks bool
+}
+
+// nodeKey identifies a model by its type and address; the type tells apart
+// a struct from an embedded struct at the same address.
+type nodeKey struct {
+	t reflect.Type
+	p uintptr
+}
+
+type visitedNode struct {
+	node *ResourceObj
+	done bool
+}
+
+type unmarshalledModel struct {
+	model reflect.Value
+	full  bool
+}
+
+func newWalk(opts *options) *walk {
+	stats := opts.stats
+	if stats == nil {
+		stats = new(Stats)
+	}
+
+	return &walk{
+		ctx:    opts.ctx,
+		opts:   opts,
+		stats:  stats,
+		nodes:  make(map[nodeKey]*visitedNode),
+		models: make(map[string]*unmarshalledModel),
+	}
+}
+
+// withoutHooks returns a walk sharing the options and stats of w that
+// visits models without calling their BeforeMarshal hooks.
+func (w *walk) withoutHooks() *walk {
+	visit := newWalk(w.opts)
+	visit.stats = w.stats
+	visit.noHooks = true
+
+	return visit
+}
+
+// register records model as the model of the primary resource data, so
+// relationships back to it share it.
+func (w *walk) register(data *ResourceObj, model reflect.Value) {
+	if data != nil && data.ID != "" {
+		w.models[fmt.Sprintf("%s,%s", data.Type, data.ID)] = &unmarshalledModel{model: model, full: true}
+	}
+}
+
+// defaultWalk returns a walk with the default options, as used by the
+// package level functions.
+func defaultWalk() *walk {
+	return newWalk(newOptions(nil))
+}
+
+// follow reports whether the relationships of resources at the current
+// depth are followed, i.e. whether their related resources are visited in
+// full rather than as resource linkage.
+func (w *walk) follow() bool {
+	return w.opts.maxDepth <= 0 || w.depth < w.opts.maxDepth
+}
+
+// linkageOnly reports whether resources at the current depth lie beyond the
+// max depth, and so are reduced to resource linkage.
+func (w *walk) linkageOnly() bool {
+	return w.opts.maxDepth > 0 && w.depth > w.opts.maxDepth
+}
+
+// reach records that a resource was visited at the current depth.
+func (w *walk) reach() {
+	if w.depth > w.stats.MaxDepth {
+		w.stats.MaxDepth = w.depth
+	}
+}
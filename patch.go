@@ -0,0 +1,377 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+// FieldSet records which members of a resource object were present in a
+// payload. It lets PATCH handlers tell an absent member apart from one that
+// was sent with its zero value or explicitly set to null.
+//
+// Attribute and relationship names share a single namespace per the JSON API
+// spec, so Nulls holds names of either kind.
+type FieldSet struct {
+	Attributes    map[string]bool
+	Relationships map[string]bool
+	Nulls         map[string]bool
+}
+
+// Has reports whether the named attribute or relationship was present.
+func (fs *FieldSet) Has(name string) bool {
+	return fs.Attributes[name] || fs.Relationships[name]
+}
+
+// IsNull reports whether the named attribute or relationship was present
+// and explicitly set to null.
+func (fs *FieldSet) IsNull(name string) bool {
+	return fs.Nulls[name]
+}
+
+// UnmarshalPayloadFields does the same as UnmarshalPayload and additionally
+// returns the set of attributes and relationships that were present in the
+// payload's primary resource. The payload is a partial update, so the
+// primary resource's "required" attributes may be absent, and neither its
+// AfterUnmarshal hook nor its Validator runs on the partly filled model; use
+// ApplyPayload to run them on the updated record. The attr tag options of
+// the attributes that were sent are still checked.
+//
+//	func UpdatePost(w http.ResponseWriter, r *http.Request) {
+//		post := new(Post)
+//
+//		fields, err := jsonapi.UnmarshalPayloadFields(r.Body, post)
+//		if err != nil {
+//			http.Error(w, err.Error(), 500)
+//			return
+//		}
+//
+//		if fields.Has("title") {
+//			// ...update the title...
+//		}
+//	}
+func UnmarshalPayloadFields(in io.Reader, model interface{}) (*FieldSet, error) {
+	return unmarshalPayloadFields(in, model, nil)
+}
+
+func unmarshalPayloadFields(in io.Reader, model interface{}, opts []Option) (*FieldSet, error) {
+	data, err := ioutil.ReadAll(limitReader(in, newOptions(opts).limits.MaxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(append([]Option{}, opts...), withPartial(), withDeferredChecks())
+	if err := NewDecoder(bytes.NewReader(data), opts...).Decode(model); err != nil {
+		return nil, err
+	}
+
+	return unmarshalFieldSet(data)
+}
+
+// ApplyPayload decodes a single resource payload and applies it onto model,
+// an existing struct instance. Only the attributes and relationships present
+// in the payload are written; members set to null are reset to their zero
+// value and everything else is left as it was.
+//
+// The "required" attributes may be absent from the payload. The
+// AfterUnmarshal hook and the Validator of model run once the payload is
+// applied, on the merged model; model is left as it was if they fail.
+//
+// model interface{} should be a pointer to a struct.
+func ApplyPayload(in io.Reader, model interface{}) (*FieldSet, error) {
+	return applyPayload(in, model, nil)
+}
+
+func applyPayload(in io.Reader, model interface{}, opts []Option) (*FieldSet, error) {
+	value := reflect.ValueOf(model)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return nil, ErrUnexpectedType
+	}
+
+	w := newWalk(newOptions(opts))
+
+	decoded := reflect.New(value.Elem().Type())
+	fields, err := unmarshalPayloadFields(in, decoded.Interface(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge into a copy, so model is untouched if the merged model fails
+	// its hook or validation.
+	merged := reflect.New(value.Elem().Type())
+	merged.Elem().Set(value.Elem())
+	if err := ApplyFields(merged.Interface(), decoded.Interface(), fields); err != nil {
+		return nil, err
+	}
+
+	if w.opts.checks {
+		if err := afterUnmarshal(w, merged, "/data"); err != nil {
+			return nil, err
+		}
+		if err := validateModel(merged, "/data"); err != nil {
+			return nil, err
+		}
+	}
+
+	value.Elem().Set(merged.Elem())
+
+	return fields, nil
+}
+
+// ApplyFields copies the attributes and relationships named in fields from
+// src onto dst. Fields marked as null in the set are reset to their zero
+// value on dst. The primary field is never copied.
+//
+// dst and src should be pointers to the same struct type.
+func ApplyFields(dst, src interface{}, fields *FieldSet) error {
+	dstValue := reflect.ValueOf(dst)
+	srcValue := reflect.ValueOf(src)
+
+	if dstValue.Kind() != reflect.Ptr || dstValue.Elem().Kind() != reflect.Struct {
+		return ErrUnexpectedType
+	}
+	if dstValue.Type() != srcValue.Type() {
+		return ErrInvalidType
+	}
+	if srcValue.IsNil() {
+		return ErrUnexpectedType
+	}
+
+	dstValue = dstValue.Elem()
+	srcValue = srcValue.Elem()
+	modelType := dstValue.Type()
+
+	for i := 0; i < modelType.NumField(); i++ {
+		tag := modelType.Field(i).Tag.Get(annotationJSONAPI)
+		if tag == "" {
+			continue
+		}
+
+		args := strings.Split(tag, annotationSeperator)
+		if len(args) < 2 {
+			return ErrBadJSONAPIStructTag
+		}
+
+		var present bool
+		switch args[0] {
+		case annotationAttribute:
+			present = fields.Attributes[args[1]]
+		case annotationRelation:
+			present = fields.Relationships[args[1]]
+		}
+		if !present {
+			continue
+		}
+
+		fieldValue := dstValue.Field(i)
+		if fields.IsNull(args[1]) {
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+			continue
+		}
+
+		fieldValue.Set(srcValue.Field(i))
+	}
+
+	return nil
+}
+
+// unmarshalFieldSet inspects the raw payload to work out which members of
+// the primary resource were sent.
+func unmarshalFieldSet(data []byte) (*FieldSet, error) {
+	payload := new(NulledPayload)
+	if err := json.Unmarshal(data, payload); err != nil {
+		return nil, err
+	}
+
+	fields := &FieldSet{
+		Attributes:    make(map[string]bool),
+		Relationships: make(map[string]bool),
+		Nulls:         make(map[string]bool),
+	}
+
+	for name, raw := range payload.Data.Attributes {
+		fields.Attributes[name] = true
+		if isJSONNull(raw) {
+			fields.Nulls[name] = true
+		}
+	}
+
+	for name, raw := range payload.Data.Relationships {
+		var relationship map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &relationship); err != nil {
+			return nil, err
+		}
+
+		// A relationship with only links or meta leaves the linkage as is.
+		linkage, ok := relationship["data"]
+		if !ok {
+			continue
+		}
+
+		fields.Relationships[name] = true
+		if isJSONNull(linkage) {
+			fields.Nulls[name] = true
+		}
+	}
+
+	return fields, nil
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+// MarshalDiff compares two instances of the same model and returns a single
+// resource payload containing only the attributes and relationships that
+// differ between them, suitable for a PATCH request body. Members that were
+// set on original but are empty on modified are sent as null, or as an empty
+// array for to-many relationships. The "type" and "id" are taken from the
+// primary field of modified. Only modified has its BeforeMarshal hook run;
+// original is left untouched.
+//
+// original and modified should be pointers to the same struct type.
+func MarshalDiff(original, modified interface{}) (*OnePayload, error) {
+	return marshalDiff(defaultWalk(), original, modified)
+}
+
+func marshalDiff(w *walk, original, modified interface{}) (*OnePayload, error) {
+	originalValue := reflect.ValueOf(original)
+	modifiedValue := reflect.ValueOf(modified)
+
+	if modifiedValue.Kind() != reflect.Ptr || modifiedValue.IsNil() ||
+		modifiedValue.Elem().Kind() != reflect.Struct {
+		return nil, ErrUnexpectedType
+	}
+	if originalValue.Type() != modifiedValue.Type() || originalValue.IsNil() {
+		return nil, ErrInvalidType
+	}
+
+	// Relationships are visited in sideload mode so that only resource
+	// linkage is compared, not the related records themselves. The original
+	// is only read, so its hooks don't run.
+	before, err := visitModelNode(w.withoutHooks(), original, &map[string]*ResourceObj{}, true, "/data")
+	if err != nil {
+		return nil, err
+	}
+	after, err := visitModelNode(w, modified, &map[string]*ResourceObj{}, true, "/data")
+	if err != nil {
+		return nil, err
+	}
+
+	node := &ResourceObj{Type: after.Type, ID: after.ID}
+
+	for name, value := range after.Attributes {
+		changed, err := jsonDiffers(before.Attributes[name], value)
+		if err != nil {
+			return nil, err
+		}
+		if _, existed := before.Attributes[name]; existed && !changed {
+			continue
+		}
+
+		if node.Attributes == nil {
+			node.Attributes = make(map[string]interface{})
+		}
+		if isNilPointer(value) {
+			value = nil
+		}
+		node.Attributes[name] = value
+	}
+
+	for name, value := range before.Attributes {
+		if _, exists := after.Attributes[name]; exists || isNilPointer(value) {
+			continue
+		}
+
+		if node.Attributes == nil {
+			node.Attributes = make(map[string]interface{})
+		}
+		node.Attributes[name] = nil
+	}
+
+	for name, relationship := range after.Relationships {
+		linkage := relationshipLinkage(relationship)
+
+		changed, err := jsonDiffers(relationshipLinkage(before.Relationships[name]), linkage)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			continue
+		}
+
+		if node.Relationships == nil {
+			node.Relationships = make(map[string]interface{})
+		}
+		node.Relationships[name] = linkage
+	}
+
+	for name, relationship := range before.Relationships {
+		if _, exists := after.Relationships[name]; exists {
+			continue
+		}
+
+		if node.Relationships == nil {
+			node.Relationships = make(map[string]interface{})
+		}
+		if _, isMany := relationship.(*RelationshipManyNode); isMany {
+			node.Relationships[name] = &RelationshipManyNode{Data: []*ResourceObj{}}
+		} else {
+			node.Relationships[name] = &RelationshipOneNode{Data: nil}
+		}
+	}
+
+	return &OnePayload{Data: node}, nil
+}
+
+// MarshalDiffPayload writes the payload built by MarshalDiff to w.
+func MarshalDiffPayload(w io.Writer, original, modified interface{}) error {
+	return marshalDiffPayload(w, original, modified, nil)
+}
+
+func marshalDiffPayload(w io.Writer, original, modified interface{}, opts []Option) error {
+	enc := NewEncoder(w, opts...)
+	visit := newWalk(enc.opts)
+
+	payload, err := marshalDiff(visit, original, modified)
+	if err != nil {
+		return err
+	}
+
+	return enc.encode(visit, payload)
+}
+
+// relationshipLinkage strips links and meta from a relationship node built by
+// visitModelNode, leaving only its resource linkage.
+func relationshipLinkage(relationship interface{}) interface{} {
+	switch r := relationship.(type) {
+	case *RelationshipOneNode:
+		return &RelationshipOneNode{Data: r.Data}
+	case *RelationshipManyNode:
+		return &RelationshipManyNode{Data: r.Data}
+	}
+
+	return nil
+}
+
+// jsonDiffers reports whether a and b encode to different JSON.
+func jsonDiffers(a, b interface{}) (bool, error) {
+	encodedA, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	encodedB, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(encodedA, encodedB), nil
+}
+
+func isNilPointer(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	return !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil()
+}
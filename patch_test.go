@@ -0,0 +1,338 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestUnmarshalPayloadFields(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "books",
+			"id": "1",
+			"attributes": {
+				"author": "",
+				"description": null
+			},
+			"relationships": {
+				"publisher": {"data": null}
+			}
+		}
+	}`
+
+	book := new(Book)
+	fields, err := jsonapi.UnmarshalPayloadFields(strings.NewReader(in), book)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fields.Has("author") {
+		t.Fatal("Was expecting author to be present")
+	}
+	if fields.IsNull("author") {
+		t.Fatal("Was not expecting author to be null")
+	}
+	if !fields.Has("description") || !fields.IsNull("description") {
+		t.Fatal("Was expecting description to be present and null")
+	}
+	if fields.Has("isbn") {
+		t.Fatal("Was not expecting isbn to be present")
+	}
+	if !fields.Relationships["publisher"] || !fields.IsNull("publisher") {
+		t.Fatal("Was expecting publisher relationship to be present and null")
+	}
+}
+
+func TestApplyPayload(t *testing.T) {
+	description := "A classic"
+	pages := uint(320)
+	book := &Book{
+		ID:          1,
+		Author:      "Ursula K. Le Guin",
+		ISBN:        "0441478123",
+		Title:       "The Left Hand of Darkness",
+		Description: &description,
+		Pages:       &pages,
+	}
+
+	in := `{
+		"data": {
+			"type": "books",
+			"id": "1",
+			"attributes": {
+				"title": "The Dispossessed",
+				"description": null
+			}
+		}
+	}`
+
+	fields, err := jsonapi.ApplyPayload(strings.NewReader(in), book)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fields.Has("title") {
+		t.Fatal("Was expecting title to be present")
+	}
+	if e, a := "The Dispossessed", book.Title; e != a {
+		t.Fatalf("Was expecting title to be `%s`, got `%s`", e, a)
+	}
+	if book.Description != nil {
+		t.Fatal("Was expecting description to be cleared")
+	}
+	if e, a := "Ursula K. Le Guin", book.Author; e != a {
+		t.Fatalf("Was expecting author to be left as `%s`, got `%s`", e, a)
+	}
+	if book.Pages == nil || *book.Pages != pages {
+		t.Fatal("Was expecting pages to be left untouched")
+	}
+}
+
+func TestApplyPayload_required(t *testing.T) {
+	author := &Author{ID: "1", Name: "Ann", Email: "ann@example.com", Role: "editor"}
+
+	// Name is required and absent; the Validator needs the stored email.
+	in := `{"data": {"type": "authors", "id": "1", "attributes": {"role": "admin"}}}`
+	if _, err := jsonapi.ApplyPayload(strings.NewReader(in), author); err != nil {
+		t.Fatal(err)
+	}
+	if author.Role != "admin" || author.Name != "Ann" {
+		t.Fatalf("Was expecting the role to be applied, got %#v", author)
+	}
+
+	in = `{"data": {"type": "authors", "id": "1", "attributes": {"email": null}}}`
+	_, err := jsonapi.ApplyPayload(strings.NewReader(in), author)
+	if _, ok := err.(jsonapi.ValidationErrors); !ok {
+		t.Fatalf("Was expecting the merged model to fail validation, got %v", err)
+	}
+	if author.Email != "ann@example.com" {
+		t.Fatalf("Was expecting the author to be left untouched, got %#v", author)
+	}
+
+	in = `{"data": {"type": "authors", "id": "1", "attributes": {"name": "A"}}}`
+	if _, err := jsonapi.ApplyPayload(strings.NewReader(in), author); err == nil {
+		t.Fatal("Was expecting the tag validation of sent attributes to run")
+	}
+}
+
+func TestUnmarshalPayloadFields_required(t *testing.T) {
+	in := `{"data": {"type": "authors", "id": "1", "attributes": {"role": "editor"}}}`
+
+	fields, err := jsonapi.UnmarshalPayloadFields(strings.NewReader(in), new(Author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields.Has("name") {
+		t.Fatal("Was expecting name to be absent")
+	}
+
+	// The Validator needs an email the PATCH left out.
+	in = `{"data": {"type": "authors", "id": "1", "attributes": {"role": "admin"}}}`
+	if _, err := jsonapi.UnmarshalPayloadFields(strings.NewReader(in), new(Author)); err != nil {
+		t.Fatalf("Was not expecting the Validator to run on a partial update, got %v", err)
+	}
+}
+
+func TestApplyPayload_relationships(t *testing.T) {
+	post := &Post{
+		ID:            1,
+		Title:         "Hello",
+		LatestComment: &Comment{ID: 5},
+		Comments:      []*Comment{{ID: 5}},
+	}
+
+	in := `{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"relationships": {
+				"latest_comment": {"data": null}
+			}
+		}
+	}`
+
+	if _, err := jsonapi.ApplyPayload(strings.NewReader(in), post); err != nil {
+		t.Fatal(err)
+	}
+
+	if post.LatestComment != nil {
+		t.Fatal("Was expecting latest_comment to be cleared")
+	}
+	if len(post.Comments) != 1 {
+		t.Fatal("Was expecting comments to be left untouched")
+	}
+	if e, a := "Hello", post.Title; e != a {
+		t.Fatalf("Was expecting title to be left as `%s`, got `%s`", e, a)
+	}
+}
+
+func TestApplyPayload_relationshipWithoutData(t *testing.T) {
+	post := &Post{ID: 1, Comments: []*Comment{{ID: 5}}}
+
+	in := `{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"relationships": {
+				"comments": {"links": {"related": "/posts/1/comments"}}
+			}
+		}
+	}`
+
+	fields, err := jsonapi.ApplyPayload(strings.NewReader(in), post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fields.Relationships["comments"] {
+		t.Fatal("Was not expecting comments to be present without data")
+	}
+	if len(post.Comments) != 1 {
+		t.Fatal("Was expecting comments to be left untouched")
+	}
+}
+
+func TestApplyFields_mismatchedTypes(t *testing.T) {
+	fields := &jsonapi.FieldSet{}
+	if err := jsonapi.ApplyFields(&Book{}, &Post{}, fields); err != jsonapi.ErrInvalidType {
+		t.Fatalf("Was expecting %v, got %v", jsonapi.ErrInvalidType, err)
+	}
+}
+
+func TestApplyFields_nilSource(t *testing.T) {
+	fields := &jsonapi.FieldSet{Attributes: map[string]bool{"title": true}}
+	if err := jsonapi.ApplyFields(&Post{}, (*Post)(nil), fields); err != jsonapi.ErrUnexpectedType {
+		t.Fatalf("Was expecting %v, got %v", jsonapi.ErrUnexpectedType, err)
+	}
+}
+
+func TestMarshalDiff(t *testing.T) {
+	description := "A classic"
+	original := &Book{
+		ID:          1,
+		Author:      "Ursula K. Le Guin",
+		Title:       "The Left Hand of Darkness",
+		Description: &description,
+		Tags:        []string{"fiction"},
+	}
+	modified := &Book{
+		ID:     1,
+		Author: "Ursula K. Le Guin",
+		Title:  "The Dispossessed",
+		Tags:   []string{"fiction"},
+	}
+
+	payload, err := jsonapi.MarshalDiff(original, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "books", payload.Data.Type; e != a {
+		t.Fatalf("Was expecting type `%s`, got `%s`", e, a)
+	}
+	if e, a := "1", payload.Data.ID; e != a {
+		t.Fatalf("Was expecting id `%s`, got `%s`", e, a)
+	}
+
+	attributes := payload.Data.Attributes
+	if e, a := 2, len(attributes); e != a {
+		t.Fatalf("Was expecting %d changed attributes, got %d: %v", e, a, attributes)
+	}
+	if e, a := "The Dispossessed", attributes["title"]; e != a {
+		t.Fatalf("Was expecting title `%s`, got `%v`", e, a)
+	}
+	if value, ok := attributes["description"]; !ok || value != nil {
+		t.Fatalf("Was expecting description to be null, got %v", value)
+	}
+	if payload.Data.Relationships != nil {
+		t.Fatalf("Was not expecting relationships, got %v", payload.Data.Relationships)
+	}
+}
+
+func TestMarshalDiff_relationships(t *testing.T) {
+	original := &Post{
+		ID:            1,
+		Title:         "Hello",
+		Comments:      []*Comment{{ID: 1}, {ID: 2}},
+		LatestComment: &Comment{ID: 2},
+	}
+	modified := &Post{
+		ID:            1,
+		Title:         "Hello",
+		Comments:      []*Comment{{ID: 1}, {ID: 2, Body: "edited"}, {ID: 3}},
+		LatestComment: nil,
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalDiffPayload(out, original, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+		t.Fatal(err)
+	}
+	data := jsonData["data"].(map[string]interface{})
+
+	if _, ok := data["attributes"]; ok {
+		t.Fatalf("Was not expecting attributes, got %v", data["attributes"])
+	}
+
+	relationships := data["relationships"].(map[string]interface{})
+	comments := relationships["comments"].(map[string]interface{})["data"].([]interface{})
+	if e, a := 3, len(comments); e != a {
+		t.Fatalf("Was expecting %d comments, got %d", e, a)
+	}
+	for _, comment := range comments {
+		if _, ok := comment.(map[string]interface{})["attributes"]; ok {
+			t.Fatal("Was expecting comments to contain resource linkage only")
+		}
+	}
+
+	latest, ok := relationships["latest_comment"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Was expecting latest_comment to be present")
+	}
+	if latest["data"] != nil {
+		t.Fatalf("Was expecting latest_comment data to be null, got %v", latest["data"])
+	}
+}
+
+func TestMarshalDiff_unchanged(t *testing.T) {
+	payload, err := jsonapi.MarshalDiff(testBlog(), testBlog())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Data.Attributes != nil || payload.Data.Relationships != nil {
+		t.Fatalf("Was expecting no changes, got %v %v",
+			payload.Data.Attributes, payload.Data.Relationships)
+	}
+}
+
+func TestMarshalDiff_originalUnchanged(t *testing.T) {
+	original := &Article{ID: "1", Body: "A rather long body", Excerpt: "Stale"}
+	modified := &Article{ID: "1", Body: "Short"}
+
+	payload, err := jsonapi.MarshalDiff(original, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := "Stale", original.Excerpt; e != a {
+		t.Fatalf("Was expecting the original excerpt `%s` to be left as is, got `%s`", e, a)
+	}
+	if e, a := "Short", payload.Data.Attributes["excerpt"]; e != a {
+		t.Fatalf("Was expecting the modified excerpt `%s`, got `%v`", e, a)
+	}
+}
+
+func TestMarshalDiff_mismatchedTypes(t *testing.T) {
+	if _, err := jsonapi.MarshalDiff(&Book{}, &Post{}); err != jsonapi.ErrInvalidType {
+		t.Fatalf("Was expecting %v, got %v", jsonapi.ErrInvalidType, err)
+	}
+}
@@ -0,0 +1,504 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidModel is returned by Registry.Register when a model's jsonapi
+// tags are malformed or its member names break the JSON API naming rules.
+// Field is the name of the offending struct field, if any.
+type ErrInvalidModel struct {
+	Type   reflect.Type
+	Field  string
+	Reason string
+}
+
+func (eim ErrInvalidModel) Error() string {
+	if eim.Field == "" {
+		return fmt.Sprintf("jsonapi: invalid model %v: %s", eim.Type, eim.Reason)
+	}
+
+	return fmt.Sprintf("jsonapi: invalid model %v, field %s: %s", eim.Type, eim.Field, eim.Reason)
+}
+
+// Cardinality tells whether a relationship links one resource or many.
+type Cardinality int
+
+const (
+	// ToOne is the cardinality of a relationship to a struct pointer.
+	ToOne Cardinality = iota
+
+	// ToMany is the cardinality of a relationship to a slice of struct
+	// pointers.
+	ToMany
+)
+
+func (c Cardinality) String() string {
+	if c == ToMany {
+		return "to-many"
+	}
+
+	return "to-one"
+}
+
+// Schema describes the resources of a registered model.
+type Schema struct {
+	// Type is the resource type name, from the primary tag.
+	Type string
+
+	// GoType is the model's struct type.
+	GoType reflect.Type
+
+	// IDKind is the kind of the primary field, or of what it points to.
+	IDKind reflect.Kind
+
+	// Attributes and Relationships are in the order of the struct fields.
+	Attributes    []AttributeSchema
+	Relationships []RelationshipSchema
+}
+
+// AttributeSchema describes an attr field of a model.
+type AttributeSchema struct {
+	// Name is the member name of the attribute.
+	Name string
+
+	// Field is the name of the struct field.
+	Field string
+
+	// GoType is the type of the struct field.
+	GoType reflect.Type
+
+	// OmitEmpty tells whether the attribute is left out of documents when
+	// the field holds its zero value.
+	OmitEmpty bool
+
+	// Options are the tag options following the name, such as "iso8601" or
+	// "max=10".
+	Options []string
+}
+
+// Option returns the parameter of the tag option named name, e.g. "10" for
+// "max=10", and whether the attribute has that option at all.
+func (as AttributeSchema) Option(name string) (string, bool) {
+	for _, option := range as.Options {
+		if option == name {
+			return "", true
+		}
+		if strings.HasPrefix(option, name+annotationValueSeperator) {
+			return option[len(name)+1:], true
+		}
+	}
+
+	return "", false
+}
+
+// RelationshipSchema describes a relation field of a model.
+type RelationshipSchema struct {
+	// Name is the member name of the relationship.
+	Name string
+
+	// Field is the name of the struct field.
+	Field string
+
+	// Cardinality tells whether the relationship links one resource or
+	// many.
+	Cardinality Cardinality
+
+	// Type is the resource type name of the related model.
+	Type string
+
+	// GoType is the struct type of the related model.
+	GoType reflect.Type
+
+	// OmitEmpty tells whether the relationship is left out of documents when
+	// it links no resources.
+	OmitEmpty bool
+}
+
+// Attribute returns the schema of the attribute named name.
+func (s *Schema) Attribute(name string) (AttributeSchema, bool) {
+	for _, attribute := range s.Attributes {
+		if attribute.Name == name {
+			return attribute, true
+		}
+	}
+
+	return AttributeSchema{}, false
+}
+
+// Relationship returns the schema of the relationship named name.
+func (s *Schema) Relationship(name string) (RelationshipSchema, bool) {
+	for _, relationship := range s.Relationships {
+		if relationship.Name == name {
+			return relationship, true
+		}
+	}
+
+	return RelationshipSchema{}, false
+}
+
+// Registry holds the schemas of models by resource type, so that a service
+// can describe its resources for documentation, validation and routing.
+//
+//	registry := jsonapi.NewRegistry()
+//	if err := registry.Register(new(Blog)); err != nil {
+//		return err
+//	}
+//	posts, ok := registry.Schema("posts")
+//
+// A Registry is safe for concurrent use. The schemas it returns are shared
+// and must not be modified.
+type Registry struct {
+	mu      sync.RWMutex
+	types   map[string]*Schema
+	goTypes map[reflect.Type]*Schema
+}
+
+// DefaultRegistry is the Registry that Register adds models to.
+var DefaultRegistry = NewRegistry()
+
+// Register adds models to DefaultRegistry, as Registry.Register.
+func Register(models ...interface{}) error {
+	return DefaultRegistry.Register(models...)
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		types:   make(map[string]*Schema),
+		goTypes: make(map[reflect.Type]*Schema),
+	}
+}
+
+// Register validates the models, as struct pointers, and adds their schemas
+// along with those of the models of their relationships. It returns an
+// ErrInvalidModel for the first malformed model, in which case none of the
+// models are registered. Registering a model again is a no-op.
+func (r *Registry) Register(models ...interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := make(map[reflect.Type]*Schema)
+	for _, model := range models {
+		t := reflect.TypeOf(model)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+			return ErrUnexpectedType
+		}
+		if err := r.stage(t.Elem(), pending); err != nil {
+			return err
+		}
+	}
+	r.commit(pending)
+
+	return nil
+}
+
+// stage adds the schemas of t and of the models it relates to to pending,
+// without registering them, and fails if their resource types are already
+// registered or pending to other models. r.mu must be held.
+func (r *Registry) stage(t reflect.Type, pending map[reflect.Type]*Schema) error {
+	if err := r.collect(t, pending); err != nil {
+		return err
+	}
+
+	types := make(map[string]*Schema, len(pending))
+	for _, schema := range pending {
+		registered, ok := r.types[schema.Type]
+		if !ok {
+			registered, ok = types[schema.Type]
+		}
+		if ok {
+			return ErrInvalidModel{
+				Type:   schema.GoType,
+				Reason: fmt.Sprintf("type %q is already registered to %v", schema.Type, registered.GoType),
+			}
+		}
+		types[schema.Type] = schema
+	}
+
+	return nil
+}
+
+// commit registers the staged schemas of pending. r.mu must be held.
+func (r *Registry) commit(pending map[reflect.Type]*Schema) {
+	for _, schema := range pending {
+		r.types[schema.Type] = schema
+		r.goTypes[schema.GoType] = schema
+	}
+}
+
+// collect builds the schema of t and of the models it relates to that are
+// neither registered nor pending.
+func (r *Registry) collect(t reflect.Type, pending map[reflect.Type]*Schema) error {
+	if _, ok := r.goTypes[t]; ok {
+		return nil
+	}
+	if _, ok := pending[t]; ok {
+		return nil
+	}
+
+	schema, err := newSchema(t)
+	if err != nil {
+		return err
+	}
+	pending[t] = schema
+
+	for _, relationship := range schema.Relationships {
+		if err := r.collect(relationship.GoType, pending); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Schema returns the schema of the resource type named resourceType.
+func (r *Registry) Schema(resourceType string) (*Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.types[resourceType]
+	return schema, ok
+}
+
+// SchemaOf returns the schema of model, a struct or struct pointer.
+func (r *Registry) SchemaOf(model interface{}) (*Schema, bool) {
+	t := reflect.TypeOf(model)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.goTypes[t]
+	return schema, ok
+}
+
+// Schemas returns the registered schemas, sorted by resource type.
+func (r *Registry) Schemas() []*Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schemas := make([]*Schema, 0, len(r.types))
+	for _, schema := range r.types {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Type < schemas[j].Type
+	})
+
+	return schemas
+}
+
+// newSchema builds and validates the schema of the model type t.
+func newSchema(t reflect.Type) (*Schema, error) {
+	schema := &Schema{GoType: t}
+	members := map[string]string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get(annotationJSONAPI)
+		if tag == "" {
+			continue
+		}
+
+		invalid := func(format string, a ...interface{}) error {
+			return ErrInvalidModel{Type: t, Field: field.Name, Reason: fmt.Sprintf(format, a...)}
+		}
+
+		args := strings.Split(tag, annotationSeperator)
+		if len(args) < 2 || args[1] == "" {
+			return nil, invalid("tag %q has no name", tag)
+		}
+		name, options := args[1], args[2:]
+
+		switch args[0] {
+		case annotationPrimary:
+			if schema.Type != "" {
+				return nil, invalid("a second primary tag")
+			}
+			if len(options) > 0 {
+				return nil, invalid("primary tag %q takes no options", tag)
+			}
+			if !ValidMemberName(name) {
+				return nil, invalid("type %q is not a valid member name", name)
+			}
+
+			kind := field.Type.Kind()
+			if kind == reflect.Ptr {
+				kind = field.Type.Elem().Kind()
+			}
+			if !validIDKind(kind) {
+				return nil, invalid("%v", ErrBadJSONAPIID)
+			}
+
+			schema.Type = name
+			schema.IDKind = kind
+
+			continue
+		case annotationAttribute:
+			if _, _, err := timeFormat(args); err != nil {
+				return nil, invalid("%v", err)
+			}
+			for _, option := range options {
+				if err := checkAttributeOption(option); err != nil {
+					return nil, invalid("%v", err)
+				}
+			}
+
+			_, omitEmpty := AttributeSchema{Options: options}.Option(annotationOmitEmpty)
+			schema.Attributes = append(schema.Attributes, AttributeSchema{
+				Name:      name,
+				Field:     field.Name,
+				GoType:    field.Type,
+				OmitEmpty: omitEmpty,
+				Options:   options,
+			})
+		case annotationRelation:
+			var omitEmpty bool
+			for _, option := range options {
+				if option != annotationOmitEmpty {
+					return nil, invalid("unknown relation option %q", option)
+				}
+				omitEmpty = true
+			}
+
+			relationship := RelationshipSchema{
+				Name:      name,
+				Field:     field.Name,
+				GoType:    field.Type,
+				OmitEmpty: omitEmpty,
+			}
+			if relationship.GoType.Kind() == reflect.Slice {
+				relationship.Cardinality = ToMany
+				relationship.GoType = relationship.GoType.Elem()
+			}
+			if relationship.GoType.Kind() != reflect.Ptr || relationship.GoType.Elem().Kind() != reflect.Struct {
+				return nil, invalid("relation %q should be a struct pointer or a slice of struct pointers", name)
+			}
+			relationship.GoType = relationship.GoType.Elem()
+
+			relationship.Type = primaryType(relationship.GoType)
+			if relationship.Type == "" {
+				return nil, invalid("relation %q is to %v, which has no primary tag", name, relationship.GoType)
+			}
+
+			schema.Relationships = append(schema.Relationships, relationship)
+		default:
+			return nil, invalid("unknown annotation %q", args[0])
+		}
+
+		if !ValidMemberName(name) {
+			return nil, invalid("%q is not a valid member name", name)
+		}
+		if name == "id" || name == "type" {
+			return nil, invalid("%q is reserved", name)
+		}
+		if other, ok := members[name]; ok {
+			return nil, invalid("member %q is also declared by field %s", name, other)
+		}
+		members[name] = field.Name
+	}
+
+	if schema.Type == "" {
+		return nil, ErrInvalidModel{Type: t, Reason: "no primary tag"}
+	}
+
+	return schema, nil
+}
+
+// primaryType returns the resource type name of the model type t, or "" if
+// it has no primary tag.
+func primaryType(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(t.Field(i).Tag.Get(annotationJSONAPI), annotationSeperator)
+		if len(args) >= 2 && args[0] == annotationPrimary {
+			return args[1]
+		}
+	}
+
+	return ""
+}
+
+// validIDKind reports whether a primary field of kind can hold an ID.
+func validIDKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// checkAttributeOption returns an error if option isn't one of the attr tag
+// options, or if its parameter is malformed.
+func checkAttributeOption(option string) error {
+	rule, param := option, ""
+	if idx := strings.Index(option, annotationValueSeperator); idx >= 0 {
+		rule, param = option[:idx], option[idx+1:]
+	}
+
+	switch rule {
+	case annotationOmitEmpty, annotationISO8601, annotationRFC3339,
+		annotationRFC3339Nano, annotationUnixMilli, annotationDate,
+		annotationRequired:
+		if param == "" {
+			return nil
+		}
+	case annotationLayout, annotationEnum:
+		if param != "" {
+			return nil
+		}
+	case annotationMin, annotationMax:
+		if _, err := strconv.ParseFloat(param, 64); err == nil {
+			return nil
+		}
+	case annotationMaxLen:
+		if _, err := strconv.Atoi(param); err == nil {
+			return nil
+		}
+	case annotationFormat:
+		if param == formatEmail {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown attr option %q", option)
+	}
+
+	return fmt.Errorf("malformed attr option %q", option)
+}
+
+// ValidMemberName reports whether name follows the JSON API naming rules for
+// member names, which also apply to resource types: it is made of letters,
+// digits and non-ASCII characters, with hyphens, underscores and spaces
+// allowed other than at the start or the end.
+//
+// see http://jsonapi.org/format/#document-member-names
+func ValidMemberName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r >= 0x80:
+		case r == '-' || r == '_' || r == ' ':
+			if i == 0 || i == len(runes)-1 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,166 @@
+package jsonapi_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestRegistrySchema(t *testing.T) {
+	registry := jsonapi.NewRegistry()
+	if err := registry.Register(new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	posts, ok := registry.Schema("posts")
+	if !ok {
+		t.Fatal("Was expecting the related posts to be registered")
+	}
+	if posts.GoType != reflect.TypeOf(Post{}) {
+		t.Fatalf("Was expecting the Go type Post, got %v", posts.GoType)
+	}
+	if posts.IDKind != reflect.Uint64 {
+		t.Fatalf("Was expecting a uint64 ID, got %v", posts.IDKind)
+	}
+
+	var names []string
+	for _, attribute := range posts.Attributes {
+		names = append(names, attribute.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"blog_id", "title", "body"}) {
+		t.Fatalf("Was expecting the attributes in field order, got %v", names)
+	}
+
+	title, ok := posts.Attribute("title")
+	if !ok || title.Field != "Title" || title.GoType != reflect.TypeOf("") {
+		t.Fatalf("Was expecting the title attribute, got %#v", title)
+	}
+
+	comments, ok := posts.Relationship("comments")
+	if !ok {
+		t.Fatal("Was expecting the comments relationship")
+	}
+	if comments.Cardinality != jsonapi.ToMany || comments.Type != "comments" ||
+		comments.GoType != reflect.TypeOf(Comment{}) {
+		t.Fatalf("Was expecting a to-many relationship to comments, got %#v", comments)
+	}
+
+	latest, _ := posts.Relationship("latest_comment")
+	if latest.Cardinality != jsonapi.ToOne {
+		t.Fatalf("Was expecting a to-one relationship, got %v", latest.Cardinality)
+	}
+
+	if _, ok := registry.Schema("comments"); !ok {
+		t.Fatal("Was expecting the comments to be registered transitively")
+	}
+}
+
+func TestRegistryAttributeOptions(t *testing.T) {
+	registry := jsonapi.NewRegistry()
+	if err := registry.Register(new(Author), new(Timestamp)); err != nil {
+		t.Fatal(err)
+	}
+
+	authors, _ := registry.Schema("authors")
+	role, _ := authors.Attribute("role")
+	if !role.OmitEmpty {
+		t.Fatal("Was expecting role to be omitempty")
+	}
+	if enum, ok := role.Option("enum"); !ok || enum != "admin|editor" {
+		t.Fatalf("Was expecting the enum option, got %q", enum)
+	}
+	if _, ok := role.Option("required"); ok {
+		t.Fatal("Was expecting role not to be required")
+	}
+
+	timestamps, _ := registry.Schema("timestamps")
+	next, _ := timestamps.Attribute("next")
+	if _, ok := next.Option("iso8601"); !ok {
+		t.Fatalf("Was expecting the iso8601 option, got %v", next.Options)
+	}
+}
+
+func TestRegistryCycles(t *testing.T) {
+	registry := jsonapi.NewRegistry()
+	if err := registry.Register(new(Story)); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := registry.Schemas()
+	if len(schemas) != 2 || schemas[0].Type != "people" || schemas[1].Type != "stories" {
+		t.Fatalf("Was expecting people and stories, got %v", schemas)
+	}
+
+	schema, ok := registry.SchemaOf(new(Person))
+	if !ok || schema.Type != "people" {
+		t.Fatalf("Was expecting the schema of Person, got %v", schema)
+	}
+
+	// Registering again is a no-op.
+	if err := registry.Register(new(Person)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryInvalidModels(t *testing.T) {
+	tests := []struct {
+		model interface{}
+		field string
+	}{
+		{new(BadModel), "ID"},
+		{new(BadMemberName), "Title"},
+		{new(BadAttributeOption), "Title"},
+		{new(DuplicateMember), "Headline"},
+		{new(ReservedMember), "Kind"},
+		{new(BadRelation), "Note"},
+		{new(FloatID), "ID"},
+	}
+
+	for _, test := range tests {
+		registry := jsonapi.NewRegistry()
+		err := registry.Register(test.model)
+
+		invalid, ok := err.(jsonapi.ErrInvalidModel)
+		if !ok {
+			t.Fatalf("Was expecting ErrInvalidModel for %T, got %v", test.model, err)
+		}
+		if invalid.Field != test.field {
+			t.Fatalf("Was expecting field %s of %T to be invalid, got %v", test.field, test.model, err)
+		}
+		if len(registry.Schemas()) != 0 {
+			t.Fatalf("Was expecting nothing to be registered for %T", test.model)
+		}
+	}
+}
+
+func TestRegistryTypeConflict(t *testing.T) {
+	registry := jsonapi.NewRegistry()
+	if err := registry.Register(new(Story)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := registry.Register(new(OtherStory)).(jsonapi.ErrInvalidModel); !ok {
+		t.Fatal("Was expecting ErrInvalidModel for a type registered twice")
+	}
+
+	if err := registry.Register(Story{}); err != jsonapi.ErrUnexpectedType {
+		t.Fatalf("Was expecting ErrUnexpectedType, got %v", err)
+	}
+}
+
+func TestValidMemberName(t *testing.T) {
+	valid := []string{"title", "view_count", "current-post", "Ünïcode", "a b", "x1"}
+	invalid := []string{"", "-title", "title_", " a", "a.b", "a/b", "@meta", "a+b"}
+
+	for _, name := range valid {
+		if !jsonapi.ValidMemberName(name) {
+			t.Fatalf("Was expecting %q to be valid", name)
+		}
+	}
+	for _, name := range invalid {
+		if jsonapi.ValidMemberName(name) {
+			t.Fatalf("Was expecting %q to be invalid", name)
+		}
+	}
+}
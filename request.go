@@ -2,6 +2,8 @@ package jsonapi
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,13 +25,22 @@ var (
 	// ErrInvalidISO8601 is returned when a struct has a time.Time type field and includes
 	// "iso8601" in the tag spec, but the JSON value was not an ISO8601 timestamp string.
 	ErrInvalidISO8601 = errors.New("only strings can be parsed as dates, ISO8601 timestamps")
+	// ErrInvalidTimeFormat is returned when a struct has a time.Time type field and
+	// includes "rfc3339", "rfc3339nano", "date" or "layout=..." in the tag spec, but
+	// the JSON value was not a string in that format.
+	ErrInvalidTimeFormat = errors.New("only strings in the tagged format can be parsed as dates")
+	// ErrInvalidLayout is returned when the "layout=..." of a time attribute
+	// contains a comma, which the tag spec reads as the end of the option.
+	ErrInvalidLayout = errors.New("the layout of a time attribute can't contain a comma")
 	// ErrUnknownFieldNumberType is returned when the JSON value was a float
 	// (numeric) but the Struct field was a non numeric type (i.e. not int, uint,
 	// float, etc)
 	ErrUnknownFieldNumberType = errors.New("the struct field was not of a known number type")
 	// ErrInvalidType is returned when the given type is incompatible with the expected type.
 	ErrInvalidType = errors.New("invalid type provided") // I wish we used punctuation.
-
+	// ErrInvalidLinkage is returned when the data of a to-many relationship
+	// holds something other than resource identifier objects.
+	ErrInvalidLinkage = errors.New("relationship data should be resource identifier objects")
 )
 
 // ErrUnsupportedPtrType is returned when the Struct field was a pointer but
@@ -41,14 +52,27 @@ type ErrUnsupportedPtrType struct {
 }
 
 func (eupt ErrUnsupportedPtrType) Error() string {
-	typeName := eupt.t.Elem().Name()
-	kind := eupt.t.Elem().Kind()
+	t := eupt.t
+	description := "a"
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		description = "a pointer to"
+	}
+
+	typeName := t.Name()
+	kind := t.Kind()
 	if kind.String() != "" && kind.String() != typeName {
 		typeName = fmt.Sprintf("%s (%s)", typeName, kind.String())
 	}
+
+	valueKind := "null"
+	if eupt.rf.IsValid() {
+		valueKind = eupt.rf.Type().Kind().String()
+	}
+
 	return fmt.Sprintf(
-		"jsonapi: Can't unmarshal %+v (%s) to struct field `%s`, which is a pointer to `%s`",
-		eupt.rf, eupt.rf.Type().Kind(), eupt.structField.Name, typeName,
+		"jsonapi: Can't unmarshal %+v (%s) to struct field `%s`, which is %s `%s`",
+		eupt.rf, valueKind, eupt.structField.Name, description, typeName,
 	)
 }
 
@@ -67,110 +91,66 @@ func newErrUnsupportedPtrType(rf reflect.Value, t reflect.Type, structField refl
 // For example you could pass it, in, req.Body and, model, a BlogPost
 // struct instance to populate in an http handler,
 //
-//   func CreateBlog(w http.ResponseWriter, r *http.Request) {
-//   	blog := new(Blog)
-//
-//   	if err := jsonapi.UnmarshalPayload(r.Body, blog); err != nil {
-//   		http.Error(w, err.Error(), 500)
-//   		return
-//   	}
+//	func CreateBlog(w http.ResponseWriter, r *http.Request) {
+//		blog := new(Blog)
 //
-//   	// ...do stuff with your blog...
+//		if err := jsonapi.UnmarshalPayload(r.Body, blog); err != nil {
+//			http.Error(w, err.Error(), 500)
+//			return
+//		}
 //
-//   	w.Header().Set("Content-Type", jsonapi.MediaType)
-//   	w.WriteHeader(201)
+//		// ...do stuff with your blog...
 //
-//   	if err := jsonapi.MarshalPayload(w, blog); err != nil {
-//   		http.Error(w, err.Error(), 500)
-//   	}
-//   }
+//		w.Header().Set("Content-Type", jsonapi.MediaType)
+//		w.WriteHeader(201)
 //
+//		if err := jsonapi.MarshalPayload(w, blog); err != nil {
+//			http.Error(w, err.Error(), 500)
+//		}
+//	}
 //
 // Visit https://github.com/cheeryfella/jsonapi#create for more info.
 //
 // model interface{} should be a pointer to a struct.
 func UnmarshalPayload(in io.Reader, model interface{}) error {
-	payload := new(OnePayload)
-	var duplicate bytes.Buffer
-	tee := io.TeeReader(in, &duplicate)
-	if err := json.NewDecoder(tee).Decode(payload); err != nil {
-		return err
-	}
-
-	nulls := make(map[string]interface{})
-	if err := unmarshalShadow(duplicate, nulls); err != nil {
-
-	}
-
-	if payload.Included != nil {
-		includedMap := make(map[string]*ResourceObj)
-		for _, included := range payload.Included {
-			key := fmt.Sprintf("%s,%s", included.Type, included.ID)
-			includedMap[key] = included
-		}
-
-		return unmarshalNode(payload.Data, nulls, reflect.ValueOf(model), &includedMap)
-	}
-	return unmarshalNode(payload.Data, nulls, reflect.ValueOf(model), nil)
+	return NewDecoder(in).Decode(model)
 }
 
 // UnmarshalManyPayload converts an io into a set of struct instances using
 // jsonapi tags on the type's struct fields.
 func UnmarshalManyPayload(in io.Reader, t reflect.Type) ([]interface{}, error) {
-	payload := new(ManyPayload)
-
-	if err := json.NewDecoder(in).Decode(payload); err != nil {
-		return nil, err
-	}
-
-	models := []interface{}{}                // will be populated from the "data"
-	includedMap := map[string]*ResourceObj{} // will be populate from the "included"
-
-	if payload.Included != nil {
-		for _, included := range payload.Included {
-			key := fmt.Sprintf("%s,%s", included.Type, included.ID)
-			includedMap[key] = included
-		}
-	}
-
-	for _, data := range payload.Data {
-		model := reflect.New(t.Elem())
-		nulls := make(map[string]interface{})
-
-		err := unmarshalNode(data, nulls, model, &includedMap)
-		if err != nil {
-			return nil, err
-		}
-		models = append(models, model.Interface())
-	}
-
-	return models, nil
+	return NewDecoder(in).decodeMany(t)
 }
 
-func unmarshalShadow(payload bytes.Buffer, data map[string]interface{}) (err error) {
-	v := new(NulledPayload)
-	if err := json.Unmarshal(payload.Bytes(), v); err != nil {
-		return err
-	}
-	for i := range v.Data.Attributes {
-		message := v.Data.Attributes[i]
-		if string(message) == "null" {
-			data[i] = v.Data.Attributes[i]
-		}
-	}
-	return nil
+// UnmarshalPayloadWithTopLevel populates model as UnmarshalPayload does, and
+// returns the document's top level links and meta.
+func UnmarshalPayloadWithTopLevel(in io.Reader, model interface{}) (*TopLevel, error) {
+	return NewDecoder(in).DecodeWithTopLevel(model)
 }
 
-func unmarshalNode(data *ResourceObj, nulls map[string]interface{}, model reflect.Value, included *map[string]*ResourceObj) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("data is not a jsonapi representation of '%v'\n\n%v", model.Type(), r)
-		}
-	}()
+// UnmarshalManyPayloadWithTopLevel creates models as UnmarshalManyPayload
+// does, and returns the document's top level links and meta.
+//
+//	posts, top, err := jsonapi.UnmarshalManyPayloadWithTopLevel(resp.Body, reflect.TypeOf(new(Post)))
+//	if next := top.Link(jsonapi.KeyNextPage); next != nil {
+//		// fetch next.Href
+//	}
+//	total := top.Meta["total"]
+func UnmarshalManyPayloadWithTopLevel(in io.Reader, t reflect.Type) ([]interface{}, *TopLevel, error) {
+	return NewDecoder(in).decodeManyWithTopLevel(t)
+}
 
+// unmarshalNode populates model from data. pointer is the JSON pointer of data
+// within the payload; it is empty for nested attribute structs, which are not
+// resources and are therefore neither validated nor passed to hooks.
+func unmarshalNode(w *walk, data *ResourceObj, nulls map[string]interface{}, model reflect.Value, included *map[string]*ResourceObj, pointer string) error {
 	modelValue := model.Elem()
 	modelType := modelValue.Type()
 
+	if pointer != "" {
+		w.reach()
+	}
+
 	var er error
 
 	for i := 0; i < modelValue.NumField(); i++ {
@@ -252,28 +232,23 @@ func unmarshalNode(data *ResourceObj, nulls map[string]interface{}, model reflec
 
 			attribute := attributes[args[1]]
 
+			// codecs and json.Unmarshalers decode the attribute's raw JSON,
+			// which includes an explicit null.
+			if usesRawJSON(fieldValue.Type()) {
+				if raw, ok := data.rawAttribute(args[1]); ok {
+					attribute = raw
+				} else if val, ok := nulls[args[1]]; ok {
+					attribute = val
+				}
+			}
+
 			// continue if the attribute was not included in the request
 			if attribute == nil {
-				val, ok := nulls[args[1]]
-				if !ok {
-					continue
-				}
-				var model reflect.Value
-				if fieldValue.Kind() == reflect.Ptr {
-					model = reflect.New(fieldValue.Type().Elem())
-				} else {
-					model = reflect.New(fieldValue.Type())
-				}
-				// handle custom structs which need UnmarshalJSON to work.
-				method := model.MethodByName("UnmarshalJSON")
-				if !method.IsValid() {
-					continue
-				}
-				attribute = val
+				continue
 			}
 
 			structField := fieldType
-			value, err := unmarshalAttribute(attribute, args, structField, fieldValue)
+			value, err := unmarshalAttribute(w, attribute, args, structField, fieldValue)
 			if err != nil {
 				er = err
 				break
@@ -296,18 +271,26 @@ func unmarshalNode(data *ResourceObj, nulls map[string]interface{}, model reflec
 				json.NewEncoder(buf).Encode(data.Relationships[args[1]])
 				json.NewDecoder(buf).Decode(relationship)
 
+				setRelationshipLinksAndMeta(model, args[1], relationship.Links, relationship.Meta)
+
 				data := relationship.Data
 				models := reflect.New(fieldValue.Type()).Elem()
 
-				for _, n := range data {
-					m := reflect.New(fieldValue.Type().Elem().Elem())
-					nulls := make(map[string]interface{})
-					if err := unmarshalNode(
-						fullNode(n, included),
-						nulls,
-						m,
+				for j, n := range data {
+					if n == nil {
+						er = ErrInvalidLinkage
+						break
+					}
+
+					node := relatedNode(w, n, included)
+					m, err := relatedModel(
+						w,
+						node,
+						fieldValue.Type().Elem().Elem(),
 						included,
-					); err != nil {
+						relationshipPointer(node, pointer, args[1], j),
+					)
+					if err != nil {
 						er = err
 						break
 					}
@@ -327,6 +310,8 @@ func unmarshalNode(data *ResourceObj, nulls map[string]interface{}, model reflec
 				)
 				json.NewDecoder(buf).Decode(relationship)
 
+				setRelationshipLinksAndMeta(model, args[1], relationship.Links, relationship.Meta)
+
 				/*
 					http://jsonapi.org/format/#document-resource-object-relationships
 					http://jsonapi.org/format/#document-resource-object-linkage
@@ -337,15 +322,15 @@ func unmarshalNode(data *ResourceObj, nulls map[string]interface{}, model reflec
 					continue
 				}
 
-				m := reflect.New(fieldValue.Type().Elem())
-				nulls := make(map[string]interface{})
-
-				if err := unmarshalNode(
-					fullNode(relationship.Data, included),
-					nulls,
-					m,
+				node := relatedNode(w, relationship.Data, included)
+				m, err := relatedModel(
+					w,
+					node,
+					fieldValue.Type().Elem(),
 					included,
-				); err != nil {
+					relationshipPointer(node, pointer, args[1], -1),
+				)
+				if err != nil {
 					er = err
 					break
 				}
@@ -358,9 +343,126 @@ func unmarshalNode(data *ResourceObj, nulls map[string]interface{}, model reflec
 		}
 	}
 
+	if er == nil {
+		setLinksAndMeta(model, data.Links, data.Meta)
+	}
+
+	if er == nil && pointer != "" && w.opts.strict {
+		er = checkUnknownMembers(data, modelType, pointer)
+	}
+
+	// The checks of a partial update's primary resource may be left to the
+	// model it is applied onto.
+	primary := pointer == "/data" && w.opts.partial
+	checked := w.opts.checks && !(primary && w.opts.deferChecks)
+
+	// Bare resource identifiers of relationships are not complete models,
+	// so neither their hook nor their validation runs.
+	identifier := data.isIdentifier()
+
+	if er == nil && pointer != "" && checked && !identifier {
+		er = afterUnmarshal(w, model, pointer)
+	}
+
+	if er == nil && pointer != "" && w.opts.checks && !identifier {
+		er = validateNode(data, model, pointer, !primary, checked)
+	}
+
 	return er
 }
 
+// afterUnmarshal calls the AfterUnmarshal hook of model, if it has one.
+func afterUnmarshal(w *walk, model reflect.Value, pointer string) error {
+	if hook, ok := model.Interface().(AfterUnmarshaler); ok {
+		if err := hook.AfterUnmarshal(w.ctx); err != nil {
+			return &HookError{Pointer: pointer, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// setLinksAndMeta hands the links and meta of a resource to a model that
+// implements LinksSetter or MetaSetter.
+func setLinksAndMeta(model reflect.Value, links *Links, meta *Meta) {
+	if setter, ok := model.Interface().(LinksSetter); ok && links != nil {
+		setter.SetJSONAPILinks(links)
+	}
+	if setter, ok := model.Interface().(MetaSetter); ok && meta != nil {
+		setter.SetJSONAPIMeta(meta)
+	}
+}
+
+// setRelationshipLinksAndMeta hands the links and meta of a relationship to
+// a model that implements RelationshipLinksSetter or RelationshipMetaSetter.
+func setRelationshipLinksAndMeta(model reflect.Value, relation string, links *Links, meta *Meta) {
+	if setter, ok := model.Interface().(RelationshipLinksSetter); ok && links != nil {
+		setter.SetJSONAPIRelationshipLinks(relation, links)
+	}
+	if setter, ok := model.Interface().(RelationshipMetaSetter); ok && meta != nil {
+		setter.SetJSONAPIRelationshipMeta(relation, meta)
+	}
+}
+
+// relationshipPointer returns the JSON pointer of a related resource. Nodes
+// resolved from the "included" array know their own location; embedded nodes
+// live under their parent's relationships. index is -1 for to-one
+// relationships.
+func relationshipPointer(node *ResourceObj, parent, relation string, index int) string {
+	if node.pointer != "" {
+		return node.pointer
+	}
+
+	if index < 0 {
+		return fmt.Sprintf("%s/relationships/%s/data", parent, relation)
+	}
+
+	return fmt.Sprintf("%s/relationships/%s/data/%d", parent, relation, index)
+}
+
+// relatedNode returns the node to unmarshal a related resource from: the
+// full resource while within the max depth, or just its identifier beyond.
+func relatedNode(w *walk, n *ResourceObj, included *map[string]*ResourceObj) *ResourceObj {
+	if !w.follow() {
+		return &ResourceObj{Type: n.Type, ID: n.ID}
+	}
+
+	return fullNode(n, included)
+}
+
+// relatedModel returns the model of type t for a related resource: the one
+// already unmarshalled for its type and ID within the call, so that each
+// resource becomes a single model and cycles end, or a new one populated
+// from node. A model first met as bare resource linkage is populated once
+// its full resource is met.
+func relatedModel(w *walk, node *ResourceObj, t reflect.Type, included *map[string]*ResourceObj, pointer string) (reflect.Value, error) {
+	full := !node.isIdentifier()
+
+	var model reflect.Value
+	if node.ID != "" {
+		key := fmt.Sprintf("%s,%s", node.Type, node.ID)
+		if seen, ok := w.models[key]; ok && seen.model.Type().Elem() == t {
+			if seen.full || !full {
+				return seen.model, nil
+			}
+			seen.full = true
+			model = seen.model
+		} else if !ok {
+			model = reflect.New(t)
+			w.models[key] = &unmarshalledModel{model: model, full: full}
+		}
+	}
+	if !model.IsValid() {
+		model = reflect.New(t)
+	}
+
+	w.depth++
+	err := unmarshalNode(w, node, make(map[string]interface{}), model, included, pointer)
+	w.depth--
+
+	return model, err
+}
+
 func fullNode(n *ResourceObj, included *map[string]*ResourceObj) *ResourceObj {
 	includedKey := fmt.Sprintf("%s,%s", n.Type, n.ID)
 
@@ -386,6 +488,44 @@ func assign(field, value reflect.Value) {
 	assignValue(field, value)
 }
 
+// assignable reports whether assign can set field to value.
+func assignable(field, value reflect.Value) bool {
+	value = reflect.Indirect(value)
+	if !value.IsValid() {
+		return false
+	}
+
+	fieldType := field.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16,
+		reflect.Int32, reflect.Int64:
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return true
+		}
+		return false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch value.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return true
+		}
+		return false
+	case reflect.Float32, reflect.Float64:
+		return value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64
+	case reflect.String:
+		return value.Kind() == reflect.String
+	case reflect.Bool:
+		return value.Kind() == reflect.Bool
+	}
+
+	return value.Type().AssignableTo(fieldType)
+}
+
 // assign assigns the specified value to the field,
 // expecting both values not to be pointer types.
 func assignValue(field, value reflect.Value) {
@@ -409,6 +549,7 @@ func assignValue(field, value reflect.Value) {
 
 // unmarshalAttribute will unmarshall each attribute field.
 func unmarshalAttribute(
+	w *walk,
 	attribute interface{},
 	args []string,
 	structField reflect.StructField,
@@ -417,28 +558,45 @@ func unmarshalAttribute(
 	//value = reflect.ValueOf(attribute)
 	fieldType := structField.Type
 
-	value, err = handleField(attribute, args, fieldType, fieldValue)
+	value, err = handleField(w, attribute, args, fieldType, fieldValue)
+	if _, ok := err.(ErrAttributeDecode); ok {
+		return reflect.Value{}, err
+	}
+
 	switch {
 	case err == ErrInvalidType:
 		return reflect.Value{}, ErrInvalidType
 	case err == ErrInvalidISO8601:
 		return reflect.Value{}, ErrInvalidISO8601
+	case err == ErrInvalidTime, err == ErrInvalidTimeFormat, err == ErrInvalidLayout:
+		return reflect.Value{}, err
 	case err != nil:
 		return reflect.Value{},
 			newErrUnsupportedPtrType(reflect.ValueOf(attribute), fieldType, structField)
 	}
 
+	if !assignable(fieldValue, value) {
+		return reflect.Value{}, ErrInvalidType
+	}
+
 	return
 }
 
 // handleField parses each individual field given its type and value. The method allows for recursion when unmarshalling
 // so we can traverse to primitive types.
 func handleField(
+	w *walk,
 	attribute interface{},
 	args []string,
 	fieldType reflect.Type,
 	fieldValue reflect.Value) (value reflect.Value, err error) {
 
+	if fieldType.Kind() != reflect.Ptr {
+		if value, ok, err := decodeAttribute(attribute, fieldType); ok {
+			return value, err
+		}
+	}
+
 	value = reflect.ValueOf(attribute)
 	switch fieldType.Kind() {
 	case reflect.Bool:
@@ -484,19 +642,20 @@ func handleField(
 		val, err := handleString(attribute, fieldType, fieldValue)
 		return reflect.ValueOf(val), err
 	case reflect.Slice:
-		switch reflect.TypeOf(fieldValue.Interface()).Elem().Kind() {
-		case reflect.Struct:
-			return handleStructSlice(attribute, fieldValue)
-		default:
-			return handleSlice(attribute, args, fieldType, fieldValue)
-		}
+		return handleSlice(w, attribute, args, fieldType, fieldValue)
+	case reflect.Array:
+		return handleArray(w, attribute, args, fieldType, fieldValue)
+	case reflect.Map:
+		return handleMap(w, attribute, args, fieldType, fieldValue)
+	case reflect.Interface:
+		return handleInterface(attribute, fieldType)
 	case reflect.Ptr:
-		return handlePointer(attribute, args, fieldType, fieldValue)
+		return handlePointer(w, attribute, args, fieldType, fieldValue)
 	case reflect.Struct:
-		if fieldType.ConvertibleTo(reflect.TypeOf(time.Time{})) {
-			return handleTime(attribute, args, fieldValue)
+		if fieldType.ConvertibleTo(timeType) {
+			return handleTime(attribute, args)
 		}
-		return handleStruct(attribute, fieldValue)
+		return handleStruct(w, attribute, fieldType)
 	}
 
 	return
@@ -517,7 +676,10 @@ func handleNumeric(
 	fieldType reflect.Type,
 	fieldValue reflect.Value) (reflect.Value, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return reflect.Value{}, ErrInvalidType
+	}
 
 	var kind reflect.Kind
 	if fieldValue.Kind() == reflect.Ptr {
@@ -586,7 +748,11 @@ func handleInt(attribute interface{}) (int, error) {
 // handleInt8
 func handleInt8(attribute interface{}) (int8, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return int8(floatValue), nil
 }
@@ -594,7 +760,11 @@ func handleInt8(attribute interface{}) (int8, error) {
 // handleInt16
 func handleInt16(attribute interface{}) (int16, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return int16(floatValue), nil
 }
@@ -602,7 +772,11 @@ func handleInt16(attribute interface{}) (int16, error) {
 // handleInt32
 func handleInt32(attribute interface{}) (int32, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return int32(floatValue), nil
 }
@@ -610,7 +784,11 @@ func handleInt32(attribute interface{}) (int32, error) {
 // handleInt64
 func handleInt64(attribute interface{}) (int64, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return int64(floatValue), nil
 }
@@ -618,7 +796,11 @@ func handleInt64(attribute interface{}) (int64, error) {
 // handleUint
 func handleUint(attribute interface{}) (uint, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return uint(floatValue), nil
 }
@@ -626,7 +808,11 @@ func handleUint(attribute interface{}) (uint, error) {
 // handleUint8
 func handleUint8(attribute interface{}) (uint8, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return uint8(floatValue), nil
 }
@@ -634,7 +820,11 @@ func handleUint8(attribute interface{}) (uint8, error) {
 // handleUint16
 func handleUint16(attribute interface{}) (uint16, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return uint16(floatValue), nil
 }
@@ -642,7 +832,11 @@ func handleUint16(attribute interface{}) (uint16, error) {
 // handleUint32
 func handleUint32(attribute interface{}) (uint32, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return uint32(floatValue), nil
 }
@@ -650,7 +844,11 @@ func handleUint32(attribute interface{}) (uint32, error) {
 // handleUint64
 func handleUint64(attribute interface{}) (uint64, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return uint64(floatValue), nil
 }
@@ -658,7 +856,11 @@ func handleUint64(attribute interface{}) (uint64, error) {
 // handleFloat32
 func handleFloat32(attribute interface{}) (float32, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return float32(floatValue), nil
 }
@@ -666,7 +868,11 @@ func handleFloat32(attribute interface{}) (float32, error) {
 // handleFloat64
 func handleFloat64(attribute interface{}) (float64, error) {
 	v := reflect.ValueOf(attribute)
-	floatValue := v.Interface().(float64)
+
+	floatValue, ok := v.Interface().(float64)
+	if !ok {
+		return 0, ErrInvalidType
+	}
 
 	return float64(floatValue), nil
 }
@@ -688,157 +894,281 @@ func handleString(
 
 // handleSlice
 func handleSlice(
+	w *walk,
 	attribute interface{},
 	args []string,
 	fieldType reflect.Type,
 	fieldValue reflect.Value) (value reflect.Value, err error) {
 
-	// check passed values is a struct
+	if attribute == nil {
+		return reflect.Zero(fieldType), nil
+	}
+
+	// encoding/json writes byte slices as base64 strings
+	if encoded, ok := attribute.(string); ok && fieldType.Elem().Kind() == reflect.Uint8 {
+		b, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b).Convert(fieldType), nil
+	}
+
+	// check passed values is a slice
 	submittedValues, ok := attribute.([]interface{})
 	if !ok {
 		return reflect.Value{}, errors.New("require slice of values to unmarshall into slice")
 	}
 
-	// find type tp pass back to handle field - recursively filling the values
-	sliceType := fieldType.Elem()
-
-	vals := reflect.MakeSlice(reflect.SliceOf(sliceType), 0, len(submittedValues))
-
-	for _, val := range submittedValues {
-		v, tErr := handleField(val, args, sliceType, fieldValue)
-		if tErr != nil {
-			return reflect.Value{}, tErr
-		}
-
-		vals = reflect.Append(vals, v)
+	vals := reflect.MakeSlice(fieldType, len(submittedValues), len(submittedValues))
+	if err := handleElements(w, submittedValues, args, vals, fieldValue); err != nil {
+		return reflect.Value{}, err
 	}
 
 	return vals, nil
 }
 
-// handlePointer
-func handlePointer(
+// handleArray
+func handleArray(
+	w *walk,
 	attribute interface{},
 	args []string,
 	fieldType reflect.Type,
 	fieldValue reflect.Value) (value reflect.Value, err error) {
-	t := fieldType.Elem()
 
-	value, err = handleField(attribute, args, t, fieldValue)
-	if err != nil {
+	submittedValues, ok := attribute.([]interface{})
+	if !ok {
+		return reflect.Value{}, errors.New("require slice of values to unmarshall into array")
+	}
+	if len(submittedValues) != fieldType.Len() {
+		return reflect.Value{}, fmt.Errorf(
+			"can't unmarshal %d values into an array of length %d", len(submittedValues), fieldType.Len())
+	}
+
+	vals := reflect.New(fieldType).Elem()
+	if err := handleElements(w, submittedValues, args, vals, fieldValue); err != nil {
 		return reflect.Value{}, err
 	}
 
-	return
+	return vals, nil
 }
 
-func handleTime(attribute interface{}, args []string, fieldValue reflect.Value) (reflect.Value, error) {
-	var isIso8601 bool
-	v := reflect.ValueOf(attribute)
+// handleElements fills each element of the slice or array vals from the
+// submitted values, recursively handling the element type.
+func handleElements(
+	w *walk,
+	submittedValues []interface{},
+	args []string,
+	vals reflect.Value,
+	fieldValue reflect.Value) error {
 
-	if len(args) > 2 {
-		for _, arg := range args[2:] {
-			if arg == annotationISO8601 {
-				isIso8601 = true
-			}
+	elemType := vals.Type().Elem()
+
+	for i, val := range submittedValues {
+		v, err := handleField(w, val, args, elemType, fieldValue)
+		if err != nil {
+			return err
+		}
+
+		if err := setElement(vals.Index(i), v); err != nil {
+			return err
 		}
 	}
 
-	if isIso8601 {
-		var tm string
-		if v.Kind() == reflect.String {
-			tm = v.Interface().(string)
-		} else {
-			return reflect.ValueOf(time.Now()), ErrInvalidISO8601
+	return nil
+}
+
+// handleMap
+func handleMap(
+	w *walk,
+	attribute interface{},
+	args []string,
+	fieldType reflect.Type,
+	fieldValue reflect.Value) (value reflect.Value, err error) {
+
+	if attribute == nil {
+		return reflect.Zero(fieldType), nil
+	}
+
+	submittedValues, ok := attribute.(map[string]interface{})
+	if !ok {
+		return reflect.Value{}, errors.New("require object to unmarshall into map")
+	}
+
+	vals := reflect.MakeMapWithSize(fieldType, len(submittedValues))
+	elemType := fieldType.Elem()
+
+	for k, val := range submittedValues {
+		key, err := handleMapKey(k, fieldType.Key())
+		if err != nil {
+			return reflect.Value{}, err
 		}
 
-		t, err := time.Parse(iso8601TimeFormat, tm)
+		v, err := handleField(w, val, args, elemType, fieldValue)
 		if err != nil {
-			return reflect.ValueOf(time.Now()), ErrInvalidISO8601
+			return reflect.Value{}, err
 		}
 
-		if fieldValue.Kind() == reflect.Ptr {
-			return reflect.ValueOf(&t), nil
+		elem := reflect.New(elemType).Elem()
+		if err := setElement(elem, v); err != nil {
+			return reflect.Value{}, err
 		}
 
-		return reflect.ValueOf(t), nil
+		vals.SetMapIndex(key, elem)
 	}
 
-	var at int64
+	return vals, nil
+}
+
+// handleMapKey converts an object key into a map key of keyType, supporting
+// the same key types as encoding/json.
+func handleMapKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	value := reflect.New(keyType)
 
-	if v.Kind() == reflect.Float64 {
-		at = int64(v.Interface().(float64))
-	} else if v.Kind() == reflect.Int {
-		at = v.Int()
-	} else {
-		return reflect.ValueOf(time.Now()), ErrInvalidTime
+	if unmarshaler, ok := value.Interface().(encoding.TextUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, ErrAttributeDecode{keyType, err}
+		}
+		return value.Elem(), nil
 	}
 
-	t := time.Unix(at, 0)
+	switch keyType.Kind() {
+	case reflect.String:
+		value.Elem().SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, ErrInvalidType
+		}
+		value.Elem().SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, ErrInvalidType
+		}
+		value.Elem().SetUint(n)
+	default:
+		return reflect.Value{}, ErrInvalidType
+	}
 
-	return reflect.ValueOf(t), nil
+	return value.Elem(), nil
 }
 
-func handleStruct(
+// handleInterface passes the decoded JSON value through to an interface
+// field, e.g. interface{}.
+func handleInterface(
 	attribute interface{},
-	fieldValue reflect.Value) (reflect.Value, error) {
+	fieldType reflect.Type) (reflect.Value, error) {
 
-	data, err := json.Marshal(attribute)
-	if err != nil {
-		return reflect.Value{}, err
+	if attribute == nil {
+		return reflect.Zero(fieldType), nil
 	}
 
-	var model reflect.Value
-	if fieldValue.Kind() == reflect.Ptr {
-		model = reflect.New(fieldValue.Type().Elem())
-	} else {
-		model = reflect.New(fieldValue.Type())
-	}
-	// handle custom structs which need UnmarshalJSON to work.
-	method := model.MethodByName("UnmarshalJSON")
-	if method.IsValid() {
-		var buf []byte
-		if val, ok := attribute.(string); ok {
-			buf = []byte(`"` + val + `"`)
-		}
-		if val, ok := attribute.(float64); ok {
-			buf = []byte(strconv.FormatFloat(val, 'f', -1, 64))
+	value := reflect.ValueOf(attribute)
+	if !value.Type().AssignableTo(fieldType) {
+		return reflect.Value{}, ErrInvalidType
+	}
+
+	return value, nil
+}
+
+// setElement stores a value returned by handleField into dst, an element of
+// a slice, array or map. Pointers are dereferenced or kept to match dst.
+func setElement(dst, value reflect.Value) error {
+	if !value.IsValid() {
+		return nil
+	}
+
+	if value.Kind() == reflect.Ptr && dst.Kind() != reflect.Ptr {
+		if value.IsNil() {
+			return nil
 		}
-		in := []reflect.Value{reflect.ValueOf(buf)}
-		_ = method.Call(in)
+		value = value.Elem()
+	}
 
-		return model, nil
+	switch {
+	case value.Type().AssignableTo(dst.Type()):
+		dst.Set(value)
+	case value.Type().ConvertibleTo(dst.Type()):
+		dst.Set(value.Convert(dst.Type()))
+	default:
+		return ErrInvalidType
 	}
 
-	node := new(ResourceObj)
-	if err := json.Unmarshal(data, &node.Attributes); err != nil {
-		return reflect.Value{}, err
+	return nil
+}
+
+// handlePointer
+func handlePointer(
+	w *walk,
+	attribute interface{},
+	args []string,
+	fieldType reflect.Type,
+	fieldValue reflect.Value) (value reflect.Value, err error) {
+
+	if attribute == nil {
+		return reflect.Zero(fieldType), nil
 	}
 
-	nulls := make(map[string]interface{})
-	if err := unmarshalNode(node, nulls, model, nil); err != nil {
+	t := fieldType.Elem()
+
+	value, err = handleField(w, attribute, args, t, fieldValue)
+	if err != nil {
 		return reflect.Value{}, err
 	}
 
-	return model, nil
+	// Hand back a pointer so that callers such as handleSlice can use the
+	// value as an element of fieldType.
+	if value.Kind() != reflect.Ptr || value.Type() != fieldType {
+		ptr := reflect.New(t)
+		if err := setElement(ptr.Elem(), value); err != nil {
+			return reflect.Value{}, err
+		}
+		value = ptr
+	}
+
+	return
 }
 
-func handleStructSlice(
+func handleTime(attribute interface{}, args []string) (reflect.Value, error) {
+	t, err := parseTime(attribute, args)
+	if err != nil {
+		return reflect.ValueOf(time.Now()), err
+	}
+
+	return reflect.ValueOf(t), nil
+}
+
+func handleStruct(
+	w *walk,
 	attribute interface{},
-	fieldValue reflect.Value) (reflect.Value, error) {
-	models := reflect.New(fieldValue.Type()).Elem()
-	dataMap := reflect.ValueOf(attribute).Interface().([]interface{})
-	for _, data := range dataMap {
-		model := reflect.New(fieldValue.Type().Elem()).Elem()
+	fieldType reflect.Type) (reflect.Value, error) {
+
+	attributes, ok := attribute.(map[string]interface{})
+	if !ok {
+		return reflect.Value{}, errors.New("require object to unmarshall into struct")
+	}
 
-		value, err := handleStruct(data, model)
+	model := reflect.New(fieldType)
 
+	// Structs without jsonapi attr tags are decoded using their json tags.
+	if !hasAttributeTags(fieldType) {
+		data, err := json.Marshal(attributes)
 		if err != nil {
-			continue
+			return reflect.Value{}, err
+		}
+		if err := json.Unmarshal(data, model.Interface()); err != nil {
+			return reflect.Value{}, err
 		}
+		return model, nil
+	}
 
-		models = reflect.Append(models, reflect.Indirect(value))
+	node := &ResourceObj{Attributes: attributes}
+
+	nulls := make(map[string]interface{})
+	if err := unmarshalNode(w, node, nulls, model, nil, ""); err != nil {
+		return reflect.Value{}, err
 	}
 
-	return models, nil
+	return model, nil
 }
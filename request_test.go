@@ -514,8 +514,8 @@ func TestUnmarshalInvalidJSON_BadType(t *testing.T) {
 	}{ // The `Field` values here correspond to the `ModelBadTypes` jsonapi fields.
 		//"String Field": {Field: "string_field", BadValue: 0, Error: jsonapi.ErrInvalidType},  // Expected string.
 		//"Float Field": {Field: "float_field", BadValue: "A string.", Error: jsonapi.ErrInvalidType},    // Expected float64.
-		//"Time Field": {Field: "time_field", BadValue: "A string.", Error: jsonapi.ErrInvalidTime},     // Expected int64.
-		//"TimePtr Field": {Field: "time_ptr_field", BadValue: "A string.", Error: jsonapi.ErrInvalidTime}, // Expected *time / int64.
+		"Time Field":    {Field: "time_field", BadValue: "A string.", Error: jsonapi.ErrInvalidTime},     // Expected int64.
+		"TimePtr Field": {Field: "time_ptr_field", BadValue: "A string.", Error: jsonapi.ErrInvalidTime}, // Expected *time / int64.
 	}
 	for name, test := range badTypeTests {
 		t.Run(name, func(t *testing.T) {
@@ -1002,6 +1002,87 @@ func TestManyPayload_withLinks(t *testing.T) {
 	}
 }
 
+func TestUnmarshalManyPayloadWithTopLevel(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": [
+			{"type": "posts", "id": "1", "attributes": {"title": "First"}},
+			{"type": "posts", "id": "2", "attributes": {"title": "Second"}}
+		],
+		"links": {
+			"self": "http://somesite.com/posts?page[number]=1",
+			"next": {"href": "http://somesite.com/posts?page[number]=2", "meta": {"count": 2}},
+			"prev": null
+		},
+		"meta": {"total": 4}
+	}`)
+
+	posts, top, err := jsonapi.UnmarshalManyPayloadWithTopLevel(in, reflect.TypeOf(new(Post)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(posts) != 2 || posts[1].(*Post).Title != "Second" {
+		t.Fatalf("Was expecting two posts, got %v", posts)
+	}
+
+	if e, a := (&jsonapi.Link{Href: "http://somesite.com/posts?page[number]=1"}), top.Link("self"); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting links.self to be %#v, got %#v", e, a)
+	}
+	next := top.Link(jsonapi.KeyNextPage)
+	if next == nil || next.Href != "http://somesite.com/posts?page[number]=2" || next.Meta["count"] != float64(2) {
+		t.Fatalf("Was expecting the next link object, got %#v", next)
+	}
+	if _, ok := top.Links[jsonapi.KeyPreviousPage]; ok {
+		t.Fatal("Was expecting the null prev link to be left out")
+	}
+	if e, a := float64(4), top.Meta["total"]; e != a {
+		t.Fatalf("Was expecting meta.total to be %v, got %v", e, a)
+	}
+}
+
+func TestUnmarshalPayloadWithTopLevel(t *testing.T) {
+	in := strings.NewReader(`{
+		"data": {"type": "blogs", "id": "5", "attributes": {"title": "Blog"}}
+	}`)
+
+	blog := new(Blog)
+	top, err := jsonapi.UnmarshalPayloadWithTopLevel(in, blog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if blog.ID != 5 || blog.Title != "Blog" {
+		t.Fatalf("Was expecting blog 5, got %#v", blog)
+	}
+	if len(top.Links) != 0 || len(top.Meta) != 0 || top.Link("self") != nil {
+		t.Fatalf("Was expecting no links or meta, got %#v", top)
+	}
+
+	_, err = jsonapi.UnmarshalPayloadWithTopLevel(strings.NewReader(`{"data": {"type": "blogs", "id": "5"}, "links": {"self": 1}}`), new(Blog))
+	if err == nil {
+		t.Fatal("Was expecting an error for a link that is neither a string nor a link object")
+	}
+}
+
+func TestUnmarshalPayloadWithTopLevel_null(t *testing.T) {
+	in := `{"data": null, "meta": {"total": 0}}`
+
+	expected := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Blog))
+	if expected == nil {
+		t.Fatal("Was expecting UnmarshalPayload to reject null data")
+	}
+
+	_, err := jsonapi.UnmarshalPayloadWithTopLevel(strings.NewReader(in), new(Blog))
+	if err == nil || err.Error() != expected.Error() {
+		t.Fatalf("Was expecting %v, got %v", expected, err)
+	}
+
+	var blogs []*Blog
+	if _, err := jsonapi.NewDecoder(strings.NewReader(in)).DecodeWithTopLevel(&blogs); err != nil {
+		t.Fatalf("Was not expecting an error for a collection, got %v", err)
+	}
+}
+
 func TestUnmarshalCustomTypeAttributes(t *testing.T) {
 	customInt := CustomIntType(5)
 	customFloat := CustomFloatType(1.5)
@@ -43,9 +43,10 @@ func (p *ManyPayload) clearIncluded() {
 
 // ResourceObjNulls is used to represent a generic JSON API Resource with null fields
 type ResourceObjNulls struct {
-	Type       string                     `json:"type"`
-	ID         string                     `json:"id,omitempty"`
-	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
+	Type          string                     `json:"type"`
+	ID            string                     `json:"id,omitempty"`
+	Attributes    map[string]json.RawMessage `json:"attributes,omitempty"`
+	Relationships map[string]json.RawMessage `json:"relationships,omitempty"`
 }
 
 // ResourceObj is used to represent a generic JSON API Resource
@@ -56,6 +57,55 @@ type ResourceObj struct {
 	Relationships map[string]interface{} `json:"relationships,omitempty"`
 	Links         *Links                 `json:"links,omitempty"`
 	Meta          *Meta                  `json:"meta,omitempty"`
+
+	// pointer is the JSON pointer of a top level resource within the payload
+	// it was unmarshalled from, e.g. "/data" or "/included/2".
+	pointer string
+	// attributes keeps the raw JSON of the attributes object, which is split
+	// into rawAttributes only once a custom decoder needs an attribute.
+	attributes    json.RawMessage
+	rawAttributes map[string]json.RawMessage
+}
+
+// UnmarshalJSON decodes a resource object, keeping the raw JSON of its
+// attributes alongside the generic values.
+func (n *ResourceObj) UnmarshalJSON(data []byte) error {
+	type resourceObj ResourceObj
+	aux := struct {
+		*resourceObj
+		Attributes json.RawMessage `json:"attributes,omitempty"`
+	}{resourceObj: (*resourceObj)(n)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	n.Attributes = nil
+	n.attributes, n.rawAttributes = aux.Attributes, nil
+	if aux.Attributes == nil {
+		return nil
+	}
+
+	return json.Unmarshal(aux.Attributes, &n.Attributes)
+}
+
+// rawAttribute returns the raw JSON of the attribute name, splitting the
+// attributes object on first use.
+func (n *ResourceObj) rawAttribute(name string) (json.RawMessage, bool) {
+	if n.rawAttributes == nil && n.attributes != nil {
+		if err := json.Unmarshal(n.attributes, &n.rawAttributes); err != nil {
+			return nil, false
+		}
+	}
+
+	raw, ok := n.rawAttributes[name]
+	return raw, ok
+}
+
+// isIdentifier reports whether the node is a bare resource identifier object
+// taken from relationship linkage, rather than a full resource.
+func (n *ResourceObj) isIdentifier() bool {
+	return n.pointer == "" && n.Attributes == nil && n.Relationships == nil
 }
 
 // RelationshipOneNode is used to represent a generic has one JSON API relation
@@ -85,11 +135,22 @@ func (l *Links) validate() (err error) {
 	//    - href: a string containing the link’s URL.
 	//    - meta: a meta object containing non-standard meta-information about the
 	//            link.
+	if l == nil {
+		return
+	}
+
 	for k, v := range *l {
 		_, isString := v.(string)
 		_, isLink := v.(Link)
+		_, isLinkPtr := v.(*Link)
 
-		if !(isString || isLink) {
+		// A link object decoded from JSON, e.g. handed to a LinksSetter.
+		object, isObject := v.(map[string]interface{})
+		if isObject {
+			_, isObject = object["href"].(string)
+		}
+
+		if !(isString || isLink || isLinkPtr || isObject) {
 			return fmt.Errorf(
 				"The %s member of the links object was not a string or link object",
 				k,
@@ -105,6 +166,33 @@ type Link struct {
 	Meta Meta   `json:"meta,omitempty"`
 }
 
+// Link returns the member name of the links object as a Link, whether it is
+// a URL or a link object. It returns nil if the member is absent or null.
+func (l Links) Link(name string) (*Link, error) {
+	switch v := l[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &Link{Href: v}, nil
+	case Link:
+		return &v, nil
+	case *Link:
+		return v, nil
+	case map[string]interface{}:
+		link := new(Link)
+		link.Href, _ = v["href"].(string)
+		if meta, ok := v["meta"].(map[string]interface{}); ok {
+			link.Meta = Meta(meta)
+		}
+		return link, nil
+	}
+
+	return nil, fmt.Errorf(
+		"The %s member of the links object was not a string or link object",
+		name,
+	)
+}
+
 // Linkable is used to include document links in response data
 // e.g. {"self": "http://example.com/posts/1"}
 type Linkable interface {
@@ -118,6 +206,19 @@ type RelationshipLinkable interface {
 	JSONAPIRelationshipLinks(relation string) *Links
 }
 
+// LinksSetter is the unmarshalling counterpart of Linkable: it receives the
+// links of the resource a model is unmarshalled from.
+type LinksSetter interface {
+	SetJSONAPILinks(links *Links)
+}
+
+// RelationshipLinksSetter is the unmarshalling counterpart of
+// RelationshipLinkable: it receives the links of each relationship that has
+// them, with the relation name (e.g. `comments`).
+type RelationshipLinksSetter interface {
+	SetJSONAPIRelationshipLinks(relation string, links *Links)
+}
+
 // Meta is used to represent a `meta` object.
 // http://jsonapi.org/format/#document-meta
 type Meta map[string]interface{}
@@ -133,3 +234,16 @@ type RelationshipMetable interface {
 	// JSONRelationshipMeta will be invoked for each relationship with the corresponding relation name (e.g. `comments`)
 	JSONAPIRelationshipMeta(relation string) *Meta
 }
+
+// MetaSetter is the unmarshalling counterpart of Metable: it receives the
+// meta of the resource a model is unmarshalled from.
+type MetaSetter interface {
+	SetJSONAPIMeta(meta *Meta)
+}
+
+// RelationshipMetaSetter is the unmarshalling counterpart of
+// RelationshipMetable: it receives the meta of each relationship that has
+// it, with the relation name (e.g. `comments`).
+type RelationshipMetaSetter interface {
+	SetJSONAPIRelationshipMeta(relation string, meta *Meta)
+}
@@ -1,7 +1,6 @@
 package jsonapi
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -63,52 +62,15 @@ var (
 //	 }
 //
 func MarshalPayload(w io.Writer, models interface{}) error {
-	payload, err := Marshal(models)
-	if err != nil {
-		return err
-	}
-
-	return json.NewEncoder(w).Encode(payload)
+	return NewEncoder(w).Encode(models)
 }
 
 // Marshal does the same as MarshalPayload except it just returns the payload
 // and doesn't write out results. Useful if you use your own JSON rendering
 // library.
 func Marshal(models interface{}) (Payloader, error) {
-	switch vals := reflect.ValueOf(models); vals.Kind() {
-	case reflect.Slice:
-		m, err := convertToSliceInterface(&models)
-		if err != nil {
-			return nil, err
-		}
-
-		payload, err := marshalMany(m)
-		if err != nil {
-			return nil, err
-		}
-
-		if linkableModels, isLinkable := models.(Linkable); isLinkable {
-			jl := linkableModels.JSONAPILinks()
-			if er := jl.validate(); er != nil {
-				return nil, er
-			}
-			payload.Links = linkableModels.JSONAPILinks()
-		}
-
-		if metableModels, ok := models.(Metable); ok {
-			payload.Meta = metableModels.JSONAPIMeta()
-		}
-
-		return payload, nil
-	case reflect.Ptr:
-		// Check that the pointer was to a struct
-		if reflect.Indirect(vals).Kind() != reflect.Struct {
-			return nil, ErrUnexpectedType
-		}
-		return marshalOne(models)
-	default:
-		return nil, ErrUnexpectedType
-	}
+	enc := NewEncoder(nil)
+	return enc.marshal(newWalk(enc.opts), models)
 }
 
 // MarshalPayloadWithoutIncluded writes a jsonapi response with one or many
@@ -119,50 +81,55 @@ func Marshal(models interface{}) (Payloader, error) {
 // models interface{} should be either a struct pointer or a slice of struct
 // pointers.
 func MarshalPayloadWithoutIncluded(w io.Writer, model interface{}) error {
-	payload, err := Marshal(model)
-	if err != nil {
-		return err
-	}
-	payload.clearIncluded()
-
-	return json.NewEncoder(w).Encode(payload)
+	return NewEncoder(w, WithIncludeMode(IncludeNone)).Encode(model)
 }
 
 // marshalOne does the same as MarshalOnePayload except it just returns the
 // payload and doesn't write out results. Useful is you use your JSON rendering
 // library.
-func marshalOne(model interface{}) (*OnePayload, error) {
+func marshalOne(w *walk, model interface{}, sideload bool) (*OnePayload, error) {
 	included := make(map[string]*ResourceObj)
 
-	rootNode, err := visitModelNode(model, &included, true)
+	rootNode, err := visitModelNode(w, model, &included, sideload, "/data")
 	if err != nil {
 		return nil, err
 	}
 	payload := &OnePayload{Data: rootNode}
 
+	removeIncluded(&included, rootNode)
 	payload.Included = nodeMapValues(&included)
 
+	if rootNode != nil {
+		w.stats.Resources = 1
+	}
+	w.stats.Included = len(payload.Included)
+
 	return payload, nil
 }
 
 // marshalMany does the same as MarshalManyPayload except it just returns the
 // payload and doesn't write out results. Useful is you use your JSON rendering
 // library.
-func marshalMany(models []interface{}) (*ManyPayload, error) {
+func marshalMany(w *walk, models []interface{}, sideload bool) (*ManyPayload, error) {
 	payload := &ManyPayload{
 		Data: []*ResourceObj{},
 	}
 	included := map[string]*ResourceObj{}
 
-	for _, model := range models {
-		node, err := visitModelNode(model, &included, true)
+	for i, model := range models {
+		pointer := fmt.Sprintf("/data/%d", i)
+		node, err := visitModelNode(w, model, &included, sideload, pointer)
 		if err != nil {
 			return nil, err
 		}
 		payload.Data = append(payload.Data, node)
 	}
+	removeIncluded(&included, payload.Data...)
 	payload.Included = nodeMapValues(&included)
 
+	w.stats.Resources = len(payload.Data)
+	w.stats.Included = len(payload.Included)
+
 	return payload, nil
 }
 
@@ -182,18 +149,14 @@ func marshalMany(models []interface{}) (*ManyPayload, error) {
 //
 // model interface{} should be a pointer to a struct.
 func MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
-	rootNode, err := visitModelNode(model, nil, false)
-	if err != nil {
-		return err
-	}
-
-	payload := &OnePayload{Data: rootNode}
-
-	return json.NewEncoder(w).Encode(payload)
+	return NewEncoder(w, WithIncludeMode(IncludeEmbedded)).Encode(model)
 }
 
-func visitModelNode(model interface{}, included *map[string]*ResourceObj,
-	sideload bool) (*ResourceObj, error) {
+// visitModelNode builds the resource object for model. pointer is the JSON
+// pointer at which the model appears within the primary data, used to report
+// errors from BeforeMarshal hooks.
+func visitModelNode(w *walk, model interface{}, included *map[string]*ResourceObj,
+	sideload bool, pointer string) (*ResourceObj, error) {
 	node := new(ResourceObj)
 
 	var er error
@@ -202,6 +165,33 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 		return nil, nil
 	}
 
+	w.reach()
+
+	// Resources beyond the max depth are written as resource linkage only.
+	linkageOnly := w.linkageOnly()
+
+	// A model met again is not visited again. A model still being visited
+	// is an ancestor, so embedding it would never end: resource linkage is
+	// written instead.
+	var visited *visitedNode
+	if !linkageOnly {
+		key := nodeKey{t: value.Type(), p: value.Pointer()}
+		if seen, ok := w.nodes[key]; ok {
+			if !seen.done && !sideload {
+				return toShallowNode(seen.node), nil
+			}
+			return seen.node, nil
+		}
+		visited = &visitedNode{node: node}
+		w.nodes[key] = visited
+	}
+
+	if hook, ok := model.(BeforeMarshaler); ok && !linkageOnly && !w.noHooks {
+		if err := hook.BeforeMarshal(w.ctx); err != nil {
+			return nil, &HookError{Pointer: pointer, Err: err}
+		}
+	}
+
 	modelValue := value.Elem()
 	modelType := value.Type().Elem()
 
@@ -224,6 +214,10 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 
 		annotation := args[0]
 
+		if linkageOnly && annotation != annotationPrimary {
+			continue
+		}
+
 		switch {
 		case annotation == annotationPrimary:
 			v := fieldValue
@@ -274,15 +268,12 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 			node.Type = args[1]
 
 		case annotation == annotationAttribute:
-			var omitEmpty, iso8601 bool
+			var omitEmpty bool
 
 			if len(args) > 2 {
 				for _, arg := range args[2:] {
-					switch arg {
-					case annotationOmitEmpty:
+					if arg == annotationOmitEmpty {
 						omitEmpty = true
-					case annotationISO8601:
-						iso8601 = true
 					}
 				}
 			}
@@ -291,19 +282,23 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 				node.Attributes = make(map[string]interface{})
 			}
 
-			if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
+			// Registered codecs take precedence over the time handling.
+			customEncoder := hasEncoder(fieldValue.Type())
+
+			if !customEncoder && fieldValue.Type() == reflect.TypeOf(time.Time{}) {
 				t := fieldValue.Interface().(time.Time)
 
 				if t.IsZero() {
 					continue
 				}
 
-				if iso8601 {
-					node.Attributes[args[1]] = t.UTC().Format(iso8601TimeFormat)
-				} else {
-					node.Attributes[args[1]] = t.Unix()
+				value, err := formatTime(t, args)
+				if err != nil {
+					er = err
+					break
 				}
-			} else if fieldValue.Type() == reflect.TypeOf(new(time.Time)) {
+				node.Attributes[args[1]] = value
+			} else if !customEncoder && fieldValue.Type() == reflect.TypeOf(new(time.Time)) {
 				// A time pointer may be nil
 				if fieldValue.IsNil() {
 					if omitEmpty {
@@ -318,11 +313,12 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 						continue
 					}
 
-					if iso8601 {
-						node.Attributes[args[1]] = tm.UTC().Format(iso8601TimeFormat)
-					} else {
-						node.Attributes[args[1]] = tm.Unix()
+					value, err := formatTime(*tm, args)
+					if err != nil {
+						er = err
+						break
 					}
+					node.Attributes[args[1]] = value
 				}
 			} else {
 				// Dealing with a fieldValue that is not a time
@@ -333,12 +329,12 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 					continue
 				}
 
-				strAttr, ok := fieldValue.Interface().(string)
-				if ok {
-					node.Attributes[args[1]] = strAttr
-				} else {
-					node.Attributes[args[1]] = fieldValue.Interface()
+				value, err := encodeAttribute(fieldValue, args)
+				if err != nil {
+					er = err
+					break
 				}
+				node.Attributes[args[1]] = value
 			}
 
 		case annotation == annotationRelation:
@@ -370,13 +366,20 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 				relMeta = metableModel.JSONAPIRelationshipMeta(args[1])
 			}
 
+			// Related resources are only included while within the max depth.
+			follow := w.follow()
+
 			if isSlice {
 				// to-many relationship
+				w.depth++
 				relationship, err := visitModelNodeRelationships(
+					w,
 					fieldValue,
 					included,
 					sideload,
+					fmt.Sprintf("%s/relationships/%s/data", pointer, args[1]),
 				)
+				w.depth--
 				if err != nil {
 					er = err
 					break
@@ -387,7 +390,9 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 				if sideload {
 					shallowNodes := []*ResourceObj{}
 					for _, n := range relationship.Data {
-						appendIncluded(included, n)
+						if follow {
+							appendIncluded(included, n)
+						}
 						shallowNodes = append(shallowNodes, toShallowNode(n))
 					}
 
@@ -408,18 +413,24 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 					continue
 				}
 
+				w.depth++
 				relationship, err := visitModelNode(
+					w,
 					fieldValue.Interface(),
 					included,
 					sideload,
+					fmt.Sprintf("%s/relationships/%s/data", pointer, args[1]),
 				)
+				w.depth--
 				if err != nil {
 					er = err
 					break
 				}
 
 				if sideload {
-					appendIncluded(included, relationship)
+					if follow {
+						appendIncluded(included, relationship)
+					}
 					node.Relationships[args[1]] = &RelationshipOneNode{
 						Data:  toShallowNode(relationship),
 						Links: relLinks,
@@ -444,6 +455,10 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 		return nil, er
 	}
 
+	if linkageOnly {
+		return node, nil
+	}
+
 	if linkableModel, isLinkable := model.(Linkable); isLinkable {
 		jl := linkableModel.JSONAPILinks()
 		if er := jl.validate(); er != nil {
@@ -456,6 +471,8 @@ func visitModelNode(model interface{}, included *map[string]*ResourceObj,
 		node.Meta = metableModel.JSONAPIMeta()
 	}
 
+	visited.done = true
+
 	return node, nil
 }
 
@@ -466,14 +483,14 @@ func toShallowNode(node *ResourceObj) *ResourceObj {
 	}
 }
 
-func visitModelNodeRelationships(models reflect.Value, included *map[string]*ResourceObj,
-	sideload bool) (*RelationshipManyNode, error) {
+func visitModelNodeRelationships(w *walk, models reflect.Value, included *map[string]*ResourceObj,
+	sideload bool, pointer string) (*RelationshipManyNode, error) {
 	nodes := []*ResourceObj{}
 
 	for i := 0; i < models.Len(); i++ {
 		n := models.Index(i).Interface()
 
-		node, err := visitModelNode(n, included, sideload)
+		node, err := visitModelNode(w, n, included, sideload, fmt.Sprintf("%s/%d", pointer, i))
 		if err != nil {
 			return nil, err
 		}
@@ -498,6 +515,16 @@ func appendIncluded(m *map[string]*ResourceObj, nodes ...*ResourceObj) {
 	}
 }
 
+// removeIncluded removes the primary data from the included resources, which
+// relationships back to it may have added.
+func removeIncluded(m *map[string]*ResourceObj, nodes ...*ResourceObj) {
+	for _, n := range nodes {
+		if n != nil {
+			delete(*m, fmt.Sprintf("%s,%s", n.Type, n.ID))
+		}
+	}
+}
+
 func nodeMapValues(m *map[string]*ResourceObj) []*ResourceObj {
 	mp := *m
 	nodes := make([]*ResourceObj, len(mp))
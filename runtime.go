@@ -1,10 +1,12 @@
 package jsonapi
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io"
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -28,35 +30,149 @@ const (
 	// MarshalStop is the Event that is sent sent when serialization of a payload
 	// ends.
 	MarshalStop
+
+	// MarshalErrorsStart is the Event that is sent when serialization of an
+	// errors payload begins.
+	MarshalErrorsStart
+
+	// MarshalErrorsStop is the Event that is sent when serialization of an
+	// errors payload ends.
+	MarshalErrorsStop
+
+	// UnmarshalErrorsStart is the Event that is sent when deserialization of
+	// an errors payload begins.
+	UnmarshalErrorsStart
+
+	// UnmarshalErrorsStop is the Event that is sent when deserialization of
+	// an errors payload ends.
+	UnmarshalErrorsStop
+)
+
+// Outcome is the result of an instrumented call, reported with its stop
+// Event.
+type Outcome int
+
+const (
+	// OutcomeSuccess is reported when the call returned no error.
+	OutcomeSuccess Outcome = iota
+
+	// OutcomeFailure is reported when the call returned an error.
+	OutcomeFailure
 )
 
+// EventInfo describes an instrumentation event. Outcome, Err and Stats are
+// only set for stop events.
+type EventInfo struct {
+	Event    Event
+	GUID     string
+	Duration time.Duration
+	Outcome  Outcome
+	Err      error
+	Stats    Stats
+}
+
+// EventHandler is the func type of a Runtime's own instrumentation handler.
+type EventHandler func(*Runtime, EventInfo)
+
 // Runtime has the same methods as jsonapi package for serialization and
-// deserialization but also has a ctx, a map[string]interface{} for storing
-// state, designed for instrumenting serialization timings.
+// deserialization but also has a context.Context, a store of state values and
+// an event handler, designed for instrumenting serialization timings. A
+// Runtime is safe for concurrent use, and its With methods return copies, so
+// a shared Runtime can be specialized for a request.
 type Runtime struct {
-	ctx map[string]interface{}
+	mu      sync.RWMutex
+	ctx     context.Context
+	values  map[string]interface{}
+	handler EventHandler
+	tracer  Tracer
+	opts    []Option
 }
 
 // Events is the func type that provides the callback for handling event timings.
 type Events func(*Runtime, Event, string, time.Duration)
 
 // Instrumentation is a a global Events variable.  This is the handler for all
-// timing events.
+// timing events of runtimes without their own EventHandler.
 var Instrumentation Events
 
 // NewRuntime creates a Runtime for use in an application.
-func NewRuntime() *Runtime { return &Runtime{make(map[string]interface{})} }
+func NewRuntime() *Runtime {
+	return &Runtime{ctx: context.Background(), values: make(map[string]interface{})}
+}
+
+// clone returns a copy of the runtime, whose values can be changed without
+// affecting r.
+func (r *Runtime) clone() *Runtime {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values := make(map[string]interface{}, len(r.values))
+	for k, v := range r.values {
+		values[k] = v
+	}
+
+	return &Runtime{ctx: r.ctx, values: values, handler: r.handler, tracer: r.tracer, opts: r.opts}
+}
+
+// WithContext returns a copy of the runtime that carries ctx, which is
+// passed to the BeforeMarshal and AfterUnmarshal hooks of its calls.
+func (r *Runtime) WithContext(ctx context.Context) *Runtime {
+	c := r.clone()
+	c.ctx = ctx
+
+	return c
+}
+
+// Context returns the runtime's context.
+func (r *Runtime) Context() context.Context {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ctx
+}
+
+// WithEventHandler returns a copy of the runtime whose instrumentation
+// events go to handler, in place of the global Instrumentation.
+func (r *Runtime) WithEventHandler(handler EventHandler) *Runtime {
+	c := r.clone()
+	c.handler = handler
+
+	return c
+}
+
+// WithTracer returns a copy of the runtime whose tracer receives spans for
+// the phases of its calls.
+func (r *Runtime) WithTracer(tracer Tracer) *Runtime {
+	c := r.clone()
+	c.tracer = tracer
 
-// WithValue adds custom state variables to the runtime context.
+	return c
+}
+
+// WithOptions returns a copy of the runtime that adds Encoder and Decoder
+// options, such as WithLimits or WithStrict, to its calls.
+func (r *Runtime) WithOptions(opts ...Option) *Runtime {
+	c := r.clone()
+	c.opts = append(c.opts[:len(c.opts):len(c.opts)], opts...)
+
+	return c
+}
+
+// WithValue returns a copy of the runtime with a custom state variable added
+// to its context.
 func (r *Runtime) WithValue(key string, value interface{}) *Runtime {
-	r.ctx[key] = value
+	c := r.clone()
+	c.values[key] = value
 
-	return r
+	return c
 }
 
 // Value returns a state variable in the runtime context.
 func (r *Runtime) Value(key string) interface{} {
-	return r.ctx[key]
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.values[key]
 }
 
 // Instrument is deprecated.
@@ -64,21 +180,82 @@ func (r *Runtime) Instrument(key string) *Runtime {
 	return r.WithValue("instrument", key)
 }
 
-func (r *Runtime) shouldInstrument() bool {
-	return Instrumentation != nil
+// emitter returns the handler for the runtime's events, or nil if there is
+// none.
+func (r *Runtime) emitter() EventHandler {
+	r.mu.RLock()
+	handler := r.handler
+	r.mu.RUnlock()
+
+	if handler != nil {
+		return handler
+	}
+
+	if instrumentation := Instrumentation; instrumentation != nil {
+		return func(r *Runtime, info EventInfo) {
+			instrumentation(r, info.Event, info.GUID, info.Duration)
+		}
+	}
+
+	return nil
+}
+
+// options returns the Encoder and Decoder options of the runtime's calls.
+func (r *Runtime) options() []Option {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	opts := []Option{WithContext(r.ctx)}
+	if r.tracer != nil {
+		opts = append(opts, WithTracer(r.tracer))
+	}
+
+	return append(opts, r.opts...)
 }
 
 // UnmarshalPayload has docs in request.go for UnmarshalPayload.
 func (r *Runtime) UnmarshalPayload(reader io.Reader, model interface{}) error {
-	return r.instrumentCall(UnmarshalStart, UnmarshalStop, func() error {
-		return UnmarshalPayload(reader, model)
+	return r.instrumentCall(UnmarshalStart, UnmarshalStop, func(opts []Option) error {
+		return NewDecoder(reader, opts...).Decode(model)
 	})
 }
 
 // UnmarshalManyPayload has docs in request.go for UnmarshalManyPayload.
 func (r *Runtime) UnmarshalManyPayload(reader io.Reader, kind reflect.Type) (elems []interface{}, err error) {
-	r.instrumentCall(UnmarshalStart, UnmarshalStop, func() error {
-		elems, err = UnmarshalManyPayload(reader, kind)
+	err = r.instrumentCall(UnmarshalStart, UnmarshalStop, func(opts []Option) error {
+		elems, err = NewDecoder(reader, opts...).decodeMany(kind)
+		return err
+	})
+
+	return
+}
+
+// UnmarshalPayloadWithTopLevel has docs in request.go for
+// UnmarshalPayloadWithTopLevel.
+func (r *Runtime) UnmarshalPayloadWithTopLevel(reader io.Reader, model interface{}) (top *TopLevel, err error) {
+	err = r.instrumentCall(UnmarshalStart, UnmarshalStop, func(opts []Option) error {
+		top, err = NewDecoder(reader, opts...).DecodeWithTopLevel(model)
+		return err
+	})
+
+	return
+}
+
+// UnmarshalManyPayloadWithTopLevel has docs in request.go for
+// UnmarshalManyPayloadWithTopLevel.
+func (r *Runtime) UnmarshalManyPayloadWithTopLevel(reader io.Reader, kind reflect.Type) (elems []interface{}, top *TopLevel, err error) {
+	err = r.instrumentCall(UnmarshalStart, UnmarshalStop, func(opts []Option) error {
+		elems, top, err = NewDecoder(reader, opts...).decodeManyWithTopLevel(kind)
+		return err
+	})
+
+	return
+}
+
+// UnmarshalDocument has docs in document.go for UnmarshalDocument.
+func (r *Runtime) UnmarshalDocument(reader io.Reader) (doc *Document, err error) {
+	err = r.instrumentCall(UnmarshalStart, UnmarshalStop, func(opts []Option) error {
+		doc, err = NewDecoder(reader, opts...).DecodeDocument()
 		return err
 	})
 
@@ -87,14 +264,98 @@ func (r *Runtime) UnmarshalManyPayload(reader io.Reader, kind reflect.Type) (ele
 
 // MarshalPayload has docs in response.go for MarshalPayload.
 func (r *Runtime) MarshalPayload(w io.Writer, model interface{}) error {
-	return r.instrumentCall(MarshalStart, MarshalStop, func() error {
-		return MarshalPayload(w, model)
+	return r.instrumentCall(MarshalStart, MarshalStop, func(opts []Option) error {
+		return NewEncoder(w, opts...).Encode(model)
+	})
+}
+
+// UnmarshalPayloadFields has docs in patch.go for UnmarshalPayloadFields.
+func (r *Runtime) UnmarshalPayloadFields(reader io.Reader, model interface{}) (fields *FieldSet, err error) {
+	err = r.instrumentCall(UnmarshalStart, UnmarshalStop, func(opts []Option) error {
+		fields, err = unmarshalPayloadFields(reader, model, opts)
+		return err
+	})
+
+	return
+}
+
+// ApplyPayload has docs in patch.go for ApplyPayload.
+func (r *Runtime) ApplyPayload(reader io.Reader, model interface{}) (fields *FieldSet, err error) {
+	err = r.instrumentCall(UnmarshalStart, UnmarshalStop, func(opts []Option) error {
+		fields, err = applyPayload(reader, model, opts)
+		return err
+	})
+
+	return
+}
+
+// Marshal has docs in response.go for Marshal.
+func (r *Runtime) Marshal(models interface{}) (payload Payloader, err error) {
+	err = r.instrumentCall(MarshalStart, MarshalStop, func(opts []Option) error {
+		enc := NewEncoder(nil, opts...)
+		payload, err = enc.marshal(newWalk(enc.opts), models)
+		return err
+	})
+
+	return
+}
+
+// MarshalPayloadWithoutIncluded has docs in response.go for
+// MarshalPayloadWithoutIncluded.
+func (r *Runtime) MarshalPayloadWithoutIncluded(w io.Writer, model interface{}) error {
+	return r.instrumentCall(MarshalStart, MarshalStop, func(opts []Option) error {
+		return NewEncoder(w, append(opts, WithIncludeMode(IncludeNone))...).Encode(model)
 	})
 }
 
-func (r *Runtime) instrumentCall(start Event, stop Event, c func() error) error {
-	if !r.shouldInstrument() {
-		return c()
+// MarshalOnePayloadEmbedded has docs in response.go for
+// MarshalOnePayloadEmbedded.
+func (r *Runtime) MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
+	return r.instrumentCall(MarshalStart, MarshalStop, func(opts []Option) error {
+		return NewEncoder(w, append(opts, WithIncludeMode(IncludeEmbedded))...).Encode(model)
+	})
+}
+
+// MarshalDiff has docs in patch.go for MarshalDiff.
+func (r *Runtime) MarshalDiff(original, modified interface{}) (payload *OnePayload, err error) {
+	err = r.instrumentCall(MarshalStart, MarshalStop, func(opts []Option) error {
+		payload, err = marshalDiff(newWalk(newOptions(opts)), original, modified)
+		return err
+	})
+
+	return
+}
+
+// MarshalDiffPayload has docs in patch.go for MarshalDiffPayload.
+func (r *Runtime) MarshalDiffPayload(w io.Writer, original, modified interface{}) error {
+	return r.instrumentCall(MarshalStart, MarshalStop, func(opts []Option) error {
+		return marshalDiffPayload(w, original, modified, opts)
+	})
+}
+
+// MarshalErrors has docs in errors.go for MarshalErrors.
+func (r *Runtime) MarshalErrors(w io.Writer, errorObjects []*ErrorObject) error {
+	return r.instrumentCall(MarshalErrorsStart, MarshalErrorsStop, func(opts []Option) error {
+		return NewEncoder(w, opts...).EncodeErrors(errorObjects)
+	})
+}
+
+// UnmarshalErrors has docs in errors.go for UnmarshalErrors.
+func (r *Runtime) UnmarshalErrors(reader io.Reader) (errorObjects []*ErrorObject, err error) {
+	err = r.instrumentCall(UnmarshalErrorsStart, UnmarshalErrorsStop, func(opts []Option) error {
+		errorObjects, err = NewDecoder(reader, opts...).DecodeErrors()
+		return err
+	})
+
+	return
+}
+
+// instrumentCall runs c with the runtime's options, emitting the start event
+// before and the stop event after, whether or not c fails.
+func (r *Runtime) instrumentCall(start Event, stop Event, c func(opts []Option) error) error {
+	emit := r.emitter()
+	if emit == nil {
+		return c(r.options())
 	}
 
 	instrumentationGUID, err := newUUID()
@@ -102,17 +363,27 @@ func (r *Runtime) instrumentCall(start Event, stop Event, c func() error) error
 		return err
 	}
 
+	stats := new(Stats)
+
 	begin := time.Now()
-	Instrumentation(r, start, instrumentationGUID, time.Duration(0))
+	emit(r, EventInfo{Event: start, GUID: instrumentationGUID})
 
-	if err := c(); err != nil {
-		return err
-	}
+	err = c(append(r.options(), withStats(stats)))
 
-	diff := time.Duration(time.Now().UnixNano() - begin.UnixNano())
-	Instrumentation(r, stop, instrumentationGUID, diff)
+	info := EventInfo{
+		Event:    stop,
+		GUID:     instrumentationGUID,
+		Duration: time.Since(begin),
+		Outcome:  OutcomeSuccess,
+		Err:      err,
+		Stats:    *stats,
+	}
+	if err != nil {
+		info.Outcome = OutcomeFailure
+	}
+	emit(r, info)
 
-	return nil
+	return err
 }
 
 // citation: http://play.golang.org/p/4FkNSiUDMg
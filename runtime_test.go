@@ -0,0 +1,307 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+// recordEvents returns an EventHandler that records the events it receives.
+func recordEvents(events *[]jsonapi.EventInfo) jsonapi.EventHandler {
+	var mu sync.Mutex
+	return func(r *jsonapi.Runtime, info jsonapi.EventInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		*events = append(*events, info)
+	}
+}
+
+func TestRuntime_eventHandler(t *testing.T) {
+	var events []jsonapi.EventInfo
+	runtime := jsonapi.NewRuntime().WithEventHandler(recordEvents(&events))
+
+	if err := runtime.MarshalPayload(bytes.NewBuffer(nil), testBlog()); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := 2, len(events); e != a {
+		t.Fatalf("Was expecting %d events, got %d", e, a)
+	}
+	if events[0].Event != jsonapi.MarshalStart || events[1].Event != jsonapi.MarshalStop {
+		t.Fatalf("Was expecting a start and stop event, got %v", events)
+	}
+	if events[0].GUID != events[1].GUID {
+		t.Fatal("Was expecting the start and stop events to share a GUID")
+	}
+	if events[1].Outcome != jsonapi.OutcomeSuccess || events[1].Err != nil {
+		t.Fatalf("Was expecting a successful outcome, got %v %v", events[1].Outcome, events[1].Err)
+	}
+}
+
+func TestRuntime_stopEventOnFailure(t *testing.T) {
+	var events []jsonapi.EventInfo
+	runtime := jsonapi.NewRuntime().WithEventHandler(recordEvents(&events))
+
+	err := runtime.UnmarshalPayload(strings.NewReader(`{"data": `), new(Blog))
+	if err == nil {
+		t.Fatal("Was expecting an error")
+	}
+
+	if e, a := 2, len(events); e != a {
+		t.Fatalf("Was expecting %d events, got %d", e, a)
+	}
+	stop := events[1]
+	if stop.Event != jsonapi.UnmarshalStop {
+		t.Fatalf("Was expecting an UnmarshalStop event, got %v", stop.Event)
+	}
+	if stop.Outcome != jsonapi.OutcomeFailure || stop.Err != err {
+		t.Fatalf("Was expecting a failed outcome with %v, got %v %v", err, stop.Outcome, stop.Err)
+	}
+}
+
+func TestRuntime_globalInstrumentation(t *testing.T) {
+	var events []jsonapi.Event
+	jsonapi.Instrumentation = func(r *jsonapi.Runtime, e jsonapi.Event, guid string, d time.Duration) {
+		events = append(events, e)
+	}
+	defer func() { jsonapi.Instrumentation = nil }()
+
+	runtime := jsonapi.NewRuntime()
+	if _, err := runtime.UnmarshalManyPayload(strings.NewReader(`{"data": 1}`), nil); err == nil {
+		t.Fatal("Was expecting an error")
+	}
+
+	if e, a := []jsonapi.Event{jsonapi.UnmarshalStart, jsonapi.UnmarshalStop}, events; fmt.Sprint(e) != fmt.Sprint(a) {
+		t.Fatalf("Was expecting events %v, got %v", e, a)
+	}
+}
+
+func TestRuntime_context(t *testing.T) {
+	ctx := context.WithValue(context.Background(), noteKey{}, "from runtime")
+	runtime := jsonapi.NewRuntime().WithValue("instrument", "writers").WithContext(ctx)
+
+	if e, a := "writers", runtime.Value("instrument"); e != a {
+		t.Fatalf("Was expecting value `%s`, got `%v`", e, a)
+	}
+
+	writer := &Writer{ID: "1", Email: "a@example.com"}
+	if err := runtime.MarshalPayload(bytes.NewBuffer(nil), writer); err != nil {
+		t.Fatal(err)
+	}
+	if e, a := "from runtime", writer.Note; e != a {
+		t.Fatalf("Was expecting the hook to see `%s`, got `%s`", e, a)
+	}
+}
+
+func TestRuntime_options(t *testing.T) {
+	in := `{"data": {"type": "comments", "id": "1", "attributes": {"body": "foo", "rating": 5}}}`
+
+	runtime := jsonapi.NewRuntime().WithOptions(jsonapi.WithStrict(true))
+	if _, ok := runtime.UnmarshalPayload(strings.NewReader(in), new(Comment)).(jsonapi.ErrUnknownMember); !ok {
+		t.Fatal("Was expecting the runtime's strict option to reject an unknown member")
+	}
+
+	copied := runtime.WithContext(context.Background())
+	if _, ok := copied.UnmarshalPayload(strings.NewReader(in), new(Comment)).(jsonapi.ErrUnknownMember); !ok {
+		t.Fatal("Was expecting WithContext to keep the runtime's options")
+	}
+
+	limited := runtime.WithOptions(jsonapi.WithLimits(jsonapi.Limits{MaxBodyBytes: 10}))
+	if _, ok := limited.UnmarshalPayload(strings.NewReader(in), new(Comment)).(*jsonapi.ErrorObject); !ok {
+		t.Fatal("Was expecting the runtime's limits to apply")
+	}
+	if _, ok := runtime.UnmarshalPayload(strings.NewReader(in), new(Comment)).(jsonapi.ErrUnknownMember); !ok {
+		t.Fatal("Was expecting WithOptions to leave the original runtime's options")
+	}
+}
+
+func TestRuntime_copies(t *testing.T) {
+	var events []jsonapi.EventInfo
+	runtime := jsonapi.NewRuntime().WithValue("instrument", "blogs")
+
+	handled := runtime.WithEventHandler(recordEvents(&events)).WithValue("instrument", "posts")
+	if e, a := "blogs", runtime.Value("instrument"); e != a {
+		t.Fatalf("Was expecting the original value %q, got %v", e, a)
+	}
+	if e, a := "posts", handled.Value("instrument"); e != a {
+		t.Fatalf("Was expecting the copy's value %q, got %v", e, a)
+	}
+
+	if err := runtime.MarshalPayload(bytes.NewBuffer(nil), testBlog()); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("Was expecting the original runtime to have no handler, got %d events", len(events))
+	}
+
+	tracer := new(jsonapi.RecordingTracer)
+	if err := handled.WithTracer(tracer).MarshalPayload(bytes.NewBuffer(nil), testBlog()); err != nil {
+		t.Fatal(err)
+	}
+	spans := len(tracer.Spans())
+	if spans == 0 {
+		t.Fatal("Was expecting the traced copy to record spans")
+	}
+	if err := handled.MarshalPayload(bytes.NewBuffer(nil), testBlog()); err != nil {
+		t.Fatal(err)
+	}
+	if e, a := spans, len(tracer.Spans()); e != a {
+		t.Fatalf("Was expecting %d spans, got %d", e, a)
+	}
+	if e, a := 4, len(events); e != a {
+		t.Fatalf("Was expecting %d events, got %d", e, a)
+	}
+}
+
+func TestRuntime_concurrentUse(t *testing.T) {
+	var events []jsonapi.EventInfo
+	runtime := jsonapi.NewRuntime().WithEventHandler(recordEvents(&events))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			runtime.WithValue(fmt.Sprint(i), i)
+			runtime.Value(fmt.Sprint(i))
+			if err := runtime.MarshalPayload(bytes.NewBuffer(nil), testBlog()); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if e, a := 20, len(events); e != a {
+		t.Fatalf("Was expecting %d events, got %d", e, a)
+	}
+}
+
+func TestRuntime_parity(t *testing.T) {
+	blogPayload := func() *bytes.Buffer {
+		out := bytes.NewBuffer(nil)
+		if err := jsonapi.MarshalPayload(out, testBlog()); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	calls := map[string]struct {
+		start, stop jsonapi.Event
+		call        func(r *jsonapi.Runtime) error
+	}{
+		"UnmarshalPayload": {jsonapi.UnmarshalStart, jsonapi.UnmarshalStop, func(r *jsonapi.Runtime) error {
+			return r.UnmarshalPayload(blogPayload(), new(Blog))
+		}},
+		"UnmarshalPayloadWithTopLevel": {jsonapi.UnmarshalStart, jsonapi.UnmarshalStop, func(r *jsonapi.Runtime) error {
+			_, err := r.UnmarshalPayloadWithTopLevel(blogPayload(), new(Blog))
+			return err
+		}},
+		"UnmarshalManyPayloadWithTopLevel": {jsonapi.UnmarshalStart, jsonapi.UnmarshalStop, func(r *jsonapi.Runtime) error {
+			_, _, err := r.UnmarshalManyPayloadWithTopLevel(bytes.NewBufferString(`{"data": []}`), reflect.TypeOf(new(Blog)))
+			return err
+		}},
+		"UnmarshalDocument": {jsonapi.UnmarshalStart, jsonapi.UnmarshalStop, func(r *jsonapi.Runtime) error {
+			_, err := r.UnmarshalDocument(blogPayload())
+			return err
+		}},
+		"UnmarshalPayloadFields": {jsonapi.UnmarshalStart, jsonapi.UnmarshalStop, func(r *jsonapi.Runtime) error {
+			_, err := r.UnmarshalPayloadFields(blogPayload(), new(Blog))
+			return err
+		}},
+		"ApplyPayload": {jsonapi.UnmarshalStart, jsonapi.UnmarshalStop, func(r *jsonapi.Runtime) error {
+			_, err := r.ApplyPayload(blogPayload(), testBlog())
+			return err
+		}},
+		"Marshal": {jsonapi.MarshalStart, jsonapi.MarshalStop, func(r *jsonapi.Runtime) error {
+			_, err := r.Marshal(testBlog())
+			return err
+		}},
+		"MarshalPayloadWithoutIncluded": {jsonapi.MarshalStart, jsonapi.MarshalStop, func(r *jsonapi.Runtime) error {
+			return r.MarshalPayloadWithoutIncluded(bytes.NewBuffer(nil), testBlog())
+		}},
+		"MarshalOnePayloadEmbedded": {jsonapi.MarshalStart, jsonapi.MarshalStop, func(r *jsonapi.Runtime) error {
+			return r.MarshalOnePayloadEmbedded(bytes.NewBuffer(nil), testBlog())
+		}},
+		"MarshalDiff": {jsonapi.MarshalStart, jsonapi.MarshalStop, func(r *jsonapi.Runtime) error {
+			_, err := r.MarshalDiff(testBlog(), testBlog())
+			return err
+		}},
+		"MarshalDiffPayload": {jsonapi.MarshalStart, jsonapi.MarshalStop, func(r *jsonapi.Runtime) error {
+			return r.MarshalDiffPayload(bytes.NewBuffer(nil), testBlog(), testBlog())
+		}},
+		"UnmarshalErrors": {jsonapi.UnmarshalErrorsStart, jsonapi.UnmarshalErrorsStop, func(r *jsonapi.Runtime) error {
+			_, err := r.UnmarshalErrors(bytes.NewBufferString(`{"errors": [{"title": "Bad"}]}`))
+			return err
+		}},
+		"MarshalErrors": {jsonapi.MarshalErrorsStart, jsonapi.MarshalErrorsStop, func(r *jsonapi.Runtime) error {
+			return r.MarshalErrors(bytes.NewBuffer(nil), []*jsonapi.ErrorObject{{Title: "Bad"}})
+		}},
+	}
+
+	for name, c := range calls {
+		var events []jsonapi.EventInfo
+		runtime := jsonapi.NewRuntime().WithEventHandler(recordEvents(&events))
+
+		if err := c.call(runtime); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(events) != 2 || events[0].Event != c.start || events[1].Event != c.stop {
+			t.Fatalf("%s: Was expecting a %v and %v event, got %v", name, c.start, c.stop, events)
+		}
+		if events[0].GUID != events[1].GUID {
+			t.Fatalf("%s: Was expecting the start and stop events to share a GUID", name)
+		}
+	}
+}
+
+func TestRuntime_stats(t *testing.T) {
+	var events []jsonapi.EventInfo
+	runtime := jsonapi.NewRuntime().WithEventHandler(recordEvents(&events))
+
+	out := bytes.NewBuffer(nil)
+	if err := runtime.MarshalPayload(out, testBlog()); err != nil {
+		t.Fatal(err)
+	}
+	written := int64(out.Len())
+
+	if err := runtime.UnmarshalPayload(out, new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, a := 4, len(events); e != a {
+		t.Fatalf("Was expecting %d events, got %d", e, a)
+	}
+
+	// testBlog includes posts 1 and 2 and comments 1, 2 and 3.
+	expected := jsonapi.Stats{BytesWritten: written, Resources: 1, Included: 5, MaxDepth: 2}
+	if e, a := expected, events[1].Stats; e != a {
+		t.Fatalf("Was expecting marshal stats %+v, got %+v", e, a)
+	}
+
+	expected = jsonapi.Stats{BytesRead: written, Resources: 1, Included: 5, MaxDepth: 2}
+	if e, a := expected, events[3].Stats; e != a {
+		t.Fatalf("Was expecting unmarshal stats %+v, got %+v", e, a)
+	}
+}
+
+func TestRuntime_statsMany(t *testing.T) {
+	var events []jsonapi.EventInfo
+	runtime := jsonapi.NewRuntime().WithEventHandler(recordEvents(&events))
+
+	if err := runtime.MarshalPayloadWithoutIncluded(bytes.NewBuffer(nil), []*Blog{testBlog(), testBlog()}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := events[1].Stats
+	if stats.Resources != 2 || stats.Included != 0 || stats.MaxDepth != 2 {
+		t.Fatalf("Was expecting 2 resources, none included and a depth of 2, got %+v", stats)
+	}
+}
@@ -0,0 +1,91 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// ErrInvalidDocument is returned by SniffDocument for a document that has
+// neither "data", "errors" nor "meta", or has both "data" and "errors".
+var ErrInvalidDocument = errors.New("document should have either data, errors or meta")
+
+// DocumentKind is the kind of a top level JSON API document.
+type DocumentKind int
+
+const (
+	// DataDocument has primary data, possibly null.
+	DataDocument DocumentKind = iota + 1
+
+	// ErrorsDocument has errors instead of primary data.
+	ErrorsDocument
+
+	// MetaDocument has meta only, e.g. a response to a successful DELETE.
+	MetaDocument
+)
+
+func (k DocumentKind) String() string {
+	switch k {
+	case DataDocument:
+		return "data"
+	case ErrorsDocument:
+		return "errors"
+	case MetaDocument:
+		return "meta"
+	}
+
+	return "unknown"
+}
+
+// SniffDocument reads a document and tells its kind from its top level
+// members. It returns a reader of the whole document, to unmarshal it with
+// UnmarshalPayload, UnmarshalManyPayload or UnmarshalErrors accordingly.
+//
+//	kind, body, err := jsonapi.SniffDocument(resp.Body)
+//	if err != nil {
+//		return err
+//	}
+//	if kind == jsonapi.ErrorsDocument {
+//		errs, err := jsonapi.UnmarshalErrors(body)
+//		...
+//	}
+func SniffDocument(in io.Reader) (DocumentKind, io.Reader, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return 0, nil, err
+	}
+
+	kind, err := documentKind(members)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return kind, bytes.NewReader(data), nil
+}
+
+// documentKind tells the kind of a document from its top level members.
+func documentKind(members map[string]json.RawMessage) (DocumentKind, error) {
+	_, hasData := members["data"]
+	_, hasErrors := members["errors"]
+	_, hasMeta := members["meta"]
+
+	switch {
+	case hasData && hasErrors:
+		return 0, ErrInvalidDocument
+	case hasData:
+		return DataDocument, nil
+	case hasErrors:
+		return ErrorsDocument, nil
+	case hasMeta:
+		return MetaDocument, nil
+	}
+
+	return 0, ErrInvalidDocument
+}
@@ -0,0 +1,47 @@
+package jsonapi_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestSniffDocument(t *testing.T) {
+	for doc, expected := range map[string]jsonapi.DocumentKind{
+		`{"data": {"type": "blogs", "id": "1"}}`:   jsonapi.DataDocument,
+		`{"data": [], "meta": {"total": 0}}`:       jsonapi.DataDocument,
+		`{"data": null}`:                           jsonapi.DataDocument,
+		`{"errors": [{"title": "Bad"}]}`:           jsonapi.ErrorsDocument,
+		`{"meta": {"deleted": true}, "links": {}}`: jsonapi.MetaDocument,
+	} {
+		kind, body, err := jsonapi.SniffDocument(strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("%s: %v", doc, err)
+		}
+		if kind != expected {
+			t.Fatalf("%s: Was expecting a %v document, got %v", doc, expected, kind)
+		}
+
+		replayed, _ := ioutil.ReadAll(body)
+		if string(replayed) != doc {
+			t.Fatalf("Was expecting the whole document to be replayed, got %s", replayed)
+		}
+	}
+}
+
+func TestSniffDocument_invalid(t *testing.T) {
+	for _, doc := range []string{
+		`{"data": null, "errors": []}`,
+		`{"links": {"self": "/blogs"}}`,
+	} {
+		if _, _, err := jsonapi.SniffDocument(strings.NewReader(doc)); err != jsonapi.ErrInvalidDocument {
+			t.Fatalf("%s: Was expecting ErrInvalidDocument, got %v", doc, err)
+		}
+	}
+
+	if _, _, err := jsonapi.SniffDocument(strings.NewReader(`[]`)); err == nil {
+		t.Fatal("Was expecting an error for a document that isn't an object")
+	}
+}
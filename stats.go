@@ -0,0 +1,49 @@
+package jsonapi
+
+import (
+	"io"
+)
+
+// Stats are the statistics of an instrumented call, reported with its stop
+// Event.
+type Stats struct {
+	// BytesRead is the size of the document read by an unmarshal call.
+	BytesRead int64
+
+	// BytesWritten is the size of the document written by a marshal call.
+	BytesWritten int64
+
+	// Resources is the number of resources in the primary data.
+	Resources int
+
+	// Included is the number of resources in the "included" array.
+	Included int
+
+	// MaxDepth is the deepest level of relationships visited, where the
+	// primary data is at depth 0.
+	MaxDepth int
+}
+
+// countingReader counts the bytes read through it into n.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (cr countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	*cr.n += int64(n)
+	return n, err
+}
+
+// countingWriter counts the bytes written through it into n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (cw countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	*cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,414 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrUnregisteredType is returned by a Store that ingests a resource whose
+// type has no registered model.
+type ErrUnregisteredType struct {
+	Type string
+}
+
+func (eut ErrUnregisteredType) Error() string {
+	return fmt.Sprintf("jsonapi: no model registered for type %q", eut.Type)
+}
+
+// ErrNullResource is returned by a Store that ingests a document whose
+// primary data or included resources hold a null.
+var ErrNullResource = errors.New("jsonapi: resource objects should not be null")
+
+// Store is an identity map of the resources of the documents it ingests:
+// it keeps a single model per type and ID, and every document mentioning a
+// resource updates that model in place.
+//
+//	store := jsonapi.NewStore()
+//	if err := store.Register(new(Blog), new(Post)); err != nil {
+//		return err
+//	}
+//	if _, err := store.Ingest(resp.Body); err != nil {
+//		return err
+//	}
+//	post, ok := store.Find("posts", "9")
+//
+// Attributes present in a document overwrite those of the stored model,
+// null ones clear it, and absent ones are kept. Relationships with resource
+// linkage are set to the stored models of the related resources, so a
+// resource related from many places is the same pointer everywhere.
+//
+// Ingest and the lookups are safe for concurrent use, but the stored models
+// are updated in place, so callers sharing them must synchronize reads with
+// ingestion.
+type Store struct {
+	mu       sync.Mutex
+	registry *Registry
+	models   map[string]reflect.Value
+	order    map[string][]interface{}
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{
+		registry: NewRegistry(),
+		models:   make(map[string]reflect.Value),
+		order:    make(map[string][]interface{}),
+	}
+}
+
+// Register declares the models, as struct pointers, that resources are
+// unmarshalled into, along with the models of their relationships. It
+// returns an ErrInvalidModel for a malformed model, as Registry.Register.
+func (s *Store) Register(models ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.registry.Register(models...)
+}
+
+// Ingest reads a document into the store and returns the stored models of
+// its primary data, in order.
+func (s *Store) Ingest(r io.Reader) ([]interface{}, error) {
+	doc, err := UnmarshalDocument(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.IngestDocument(doc)
+}
+
+// IngestDocument adds a decoded document to the store and returns the stored
+// models of its primary data, in order.
+func (s *Store) IngestDocument(doc *Document) ([]interface{}, error) {
+	return s.ingest(doc.Data(), doc.Included)
+}
+
+func (s *Store) ingest(data, included []*ResourceObj) ([]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := append(append([]*ResourceObj{}, data...), included...)
+	for _, node := range nodes {
+		if node == nil {
+			return nil, ErrNullResource
+		}
+	}
+
+	tx := &storeTx{
+		store:   s,
+		models:  make(map[string]*stagedModel),
+		schemas: make(map[reflect.Type]*Schema),
+	}
+	w := defaultWalk()
+
+	// Merge the attributes first, so relationships can be wired to models
+	// whatever the order of the resources.
+	for _, node := range nodes {
+		model, err := tx.model(node.Type, node.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		attributes := *node
+		attributes.Relationships = nil
+		if err := unmarshalNode(w, &attributes, map[string]interface{}{}, model, nil, ""); err != nil {
+			return nil, err
+		}
+		clearNullAttributes(node, model)
+	}
+
+	for _, node := range nodes {
+		if err := tx.wire(node); err != nil {
+			return nil, err
+		}
+	}
+
+	tx.commit()
+
+	models := make([]interface{}, len(data))
+	for i, node := range data {
+		models[i] = s.models[fmt.Sprintf("%s,%s", node.Type, node.ID)].Interface()
+	}
+
+	return models, nil
+}
+
+// storeTx stages the changes of an ingestion, so the stored models and the
+// registered types are only updated once every resource of the document has
+// been read.
+type storeTx struct {
+	store   *Store
+	models  map[string]*stagedModel
+	order   []string
+	schemas map[reflect.Type]*Schema
+}
+
+// stagedModel is a copy of a stored model, or a new model, being updated.
+// Relationships point to the stored models, which commit updates in place.
+type stagedModel struct {
+	resourceType string
+	stored       reflect.Value
+	copy         reflect.Value
+	isNew        bool
+}
+
+// model returns the staged copy of a resource's model, created from the
+// stored model, the registered type, or t when the resource is referenced
+// by a relationship.
+func (tx *storeTx) model(resourceType, id string, t reflect.Type) (reflect.Value, error) {
+	staged, err := tx.stage(resourceType, id, t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return staged.copy, nil
+}
+
+// stored returns the model that relationships to a resource point to.
+func (tx *storeTx) stored(resourceType, id string, t reflect.Type) (reflect.Value, error) {
+	staged, err := tx.stage(resourceType, id, t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return staged.stored, nil
+}
+
+func (tx *storeTx) stage(resourceType, id string, t reflect.Type) (*stagedModel, error) {
+	s := tx.store
+	key := fmt.Sprintf("%s,%s", resourceType, id)
+
+	if staged, ok := tx.models[key]; ok {
+		if t != nil && staged.stored.Type().Elem() != t {
+			return nil, fmt.Errorf("jsonapi: resource %s is a %v, not a %v", key, staged.stored.Type().Elem(), t)
+		}
+		return staged, nil
+	}
+
+	if model, ok := s.models[key]; ok {
+		if t != nil && model.Type().Elem() != t {
+			return nil, fmt.Errorf("jsonapi: resource %s is a %v, not a %v", key, model.Type().Elem(), t)
+		}
+
+		staged := &stagedModel{resourceType: resourceType, stored: model, copy: reflect.New(model.Type().Elem())}
+		staged.copy.Elem().Set(model.Elem())
+		tx.models[key] = staged
+
+		return staged, nil
+	}
+
+	registered, ok := tx.registered(resourceType)
+	if !ok {
+		if t == nil {
+			return nil, ErrUnregisteredType{Type: resourceType}
+		}
+		if err := tx.register(t); err != nil {
+			return nil, err
+		}
+		if registered, ok = tx.registered(resourceType); !ok {
+			return nil, fmt.Errorf("jsonapi: resource %s is not a %v", key, t)
+		}
+	}
+	if t != nil && registered != t {
+		return nil, fmt.Errorf("jsonapi: resource %s is a %v, not a %v", key, registered, t)
+	}
+
+	model := reflect.New(registered)
+	identifier := &ResourceObj{Type: resourceType, ID: id}
+	if err := unmarshalNode(defaultWalk(), identifier, map[string]interface{}{}, model, nil, ""); err != nil {
+		return nil, err
+	}
+
+	// A new model isn't visible until the commit, so it is its own copy.
+	staged := &stagedModel{resourceType: resourceType, stored: model, copy: model, isNew: true}
+	tx.models[key] = staged
+	tx.order = append(tx.order, key)
+
+	return staged, nil
+}
+
+// registered returns the model type of resourceType, registered or staged.
+func (tx *storeTx) registered(resourceType string) (reflect.Type, bool) {
+	registry := tx.store.registry
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	if schema, ok := registry.types[resourceType]; ok {
+		return schema.GoType, true
+	}
+	for _, schema := range tx.schemas {
+		if schema.Type == resourceType {
+			return schema.GoType, true
+		}
+	}
+
+	return nil, false
+}
+
+// register stages the model type t, and those of its relationships, to be
+// registered on commit.
+func (tx *storeTx) register(t reflect.Type) error {
+	registry := tx.store.registry
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return registry.stage(t, tx.schemas)
+}
+
+// commit writes the staged copies onto the stored models, adds the new ones
+// and registers their types.
+func (tx *storeTx) commit() {
+	s := tx.store
+
+	s.registry.mu.Lock()
+	s.registry.commit(tx.schemas)
+	s.registry.mu.Unlock()
+
+	for key, staged := range tx.models {
+		if !staged.isNew {
+			staged.stored.Elem().Set(staged.copy.Elem())
+		}
+		s.models[key] = staged.stored
+	}
+
+	for _, key := range tx.order {
+		staged := tx.models[key]
+		s.order[staged.resourceType] = append(s.order[staged.resourceType], staged.stored.Interface())
+	}
+}
+
+// clearNullAttributes zeroes the fields of model whose attributes node sets
+// to null, which unmarshalNode leaves as they are. Codecs and
+// json.Unmarshalers decode the null themselves.
+func clearNullAttributes(node *ResourceObj, model reflect.Value) {
+	modelValue := model.Elem()
+	modelType := modelValue.Type()
+
+	for i := 0; i < modelType.NumField(); i++ {
+		args := strings.Split(modelType.Field(i).Tag.Get(annotationJSONAPI), annotationSeperator)
+		if len(args) < 2 || args[0] != annotationAttribute {
+			continue
+		}
+
+		value, ok := node.Attributes[args[1]]
+		field := modelValue.Field(i)
+		if !ok || value != nil || usesRawJSON(field.Type()) {
+			continue
+		}
+
+		field.Set(reflect.Zero(field.Type()))
+	}
+}
+
+// wire sets the relationships of the staged model of node that have
+// resource linkage to the stored models of the related resources.
+func (tx *storeTx) wire(node *ResourceObj) error {
+	model, err := tx.model(node.Type, node.ID, nil)
+	if err != nil {
+		return err
+	}
+	model = model.Elem()
+	modelType := model.Type()
+
+	for i := 0; i < modelType.NumField(); i++ {
+		args := strings.Split(modelType.Field(i).Tag.Get(annotationJSONAPI), annotationSeperator)
+		if len(args) < 2 || args[0] != annotationRelation {
+			continue
+		}
+
+		relationship, ok := node.Relationships[args[1]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		raw, err := json.Marshal(relationship)
+		if err != nil {
+			return err
+		}
+		var linksAndMeta struct {
+			Links *Links `json:"links"`
+			Meta  *Meta  `json:"meta"`
+		}
+		if err := json.Unmarshal(raw, &linksAndMeta); err != nil {
+			return err
+		}
+		setRelationshipLinksAndMeta(model.Addr(), args[1], linksAndMeta.Links, linksAndMeta.Meta)
+
+		linkage, ok := relationship["data"]
+		if !ok {
+			continue
+		}
+
+		field := model.Field(i)
+		if raw, err = json.Marshal(linkage); err != nil {
+			return err
+		}
+
+		if field.Kind() == reflect.Slice {
+			var identifiers []*ResourceObj
+			if err := json.Unmarshal(raw, &identifiers); err != nil {
+				return err
+			}
+
+			related := reflect.MakeSlice(field.Type(), 0, len(identifiers))
+			for _, identifier := range identifiers {
+				if identifier == nil {
+					return ErrInvalidLinkage
+				}
+
+				m, err := tx.stored(identifier.Type, identifier.ID, field.Type().Elem().Elem())
+				if err != nil {
+					return err
+				}
+				related = reflect.Append(related, m)
+			}
+			field.Set(related)
+
+			continue
+		}
+
+		var identifier *ResourceObj
+		if err := json.Unmarshal(raw, &identifier); err != nil {
+			return err
+		}
+		if identifier == nil {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+
+		m, err := tx.stored(identifier.Type, identifier.ID, field.Type().Elem())
+		if err != nil {
+			return err
+		}
+		field.Set(m)
+	}
+
+	return nil
+}
+
+// Find returns the stored model of a resource, as a struct pointer.
+func (s *Store) Find(resourceType, id string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	model, ok := s.models[fmt.Sprintf("%s,%s", resourceType, id)]
+	if !ok {
+		return nil, false
+	}
+
+	return model.Interface(), true
+}
+
+// All returns the stored models of a type, in the order they were first
+// seen.
+func (s *Store) All(resourceType string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]interface{}(nil), s.order[resourceType]...)
+}
@@ -0,0 +1,215 @@
+package jsonapi_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestStoreSharesModels(t *testing.T) {
+	store := jsonapi.NewStore()
+	if err := store.Register(new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	models, err := store.Ingest(strings.NewReader(`{
+		"data": {
+			"type": "blogs",
+			"id": "5",
+			"attributes": {"title": "Blog"},
+			"relationships": {
+				"posts": {"data": [{"type": "posts", "id": "1"}, {"type": "posts", "id": "2"}]},
+				"current_post": {"data": {"type": "posts", "id": "2"}}
+			}
+		},
+		"included": [
+			{"type": "posts", "id": "1", "attributes": {"title": "First", "body": "Body"}},
+			{
+				"type": "posts",
+				"id": "2",
+				"attributes": {"title": "Second"},
+				"relationships": {"latest_comment": {"data": {"type": "comments", "id": "7"}}}
+			}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blog := models[0].(*Blog)
+	if blog.ID != 5 || blog.Title != "Blog" {
+		t.Fatalf("Was expecting blog 5, got %#v", blog)
+	}
+	if len(blog.Posts) != 2 || blog.CurrentPost != blog.Posts[1] {
+		t.Fatal("Was expecting the current post to be the second post")
+	}
+
+	comment, ok := store.Find("comments", "7")
+	if !ok || blog.Posts[1].LatestComment != comment {
+		t.Fatal("Was expecting a stored comment, referenced by the post")
+	}
+
+	// A later document updates the same models.
+	models, err = store.Ingest(strings.NewReader(`{
+		"data": [{"type": "posts", "id": "1", "attributes": {"title": "First, edited"}}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if models[0] != blog.Posts[0] {
+		t.Fatal("Was expecting the stored post to be returned")
+	}
+	if blog.Posts[0].Title != "First, edited" || blog.Posts[0].Body != "Body" {
+		t.Fatalf("Was expecting the new title to be merged, got %#v", blog.Posts[0])
+	}
+
+	if posts := store.All("posts"); len(posts) != 2 || posts[0] != blog.Posts[0] {
+		t.Fatalf("Was expecting the two posts in order, got %v", posts)
+	}
+}
+
+func TestStoreNullRelationship(t *testing.T) {
+	store := jsonapi.NewStore()
+	if err := store.Register(new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Ingest(strings.NewReader(`{
+		"data": {"type": "blogs", "id": "5", "relationships": {"current_post": {"data": {"type": "posts", "id": "2"}}}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Relationships without linkage are kept, null linkage clears them.
+	if _, err := store.Ingest(strings.NewReader(`{
+		"data": {"type": "blogs", "id": "5", "relationships": {"current_post": {"links": {"related": "/blogs/5/current_post"}}}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	model, _ := store.Find("blogs", "5")
+	blog := model.(*Blog)
+	if blog.CurrentPost == nil || blog.CurrentPost.ID != 2 {
+		t.Fatalf("Was expecting the current post to be kept, got %#v", blog.CurrentPost)
+	}
+
+	if _, err := store.Ingest(strings.NewReader(`{
+		"data": {"type": "blogs", "id": "5", "relationships": {"current_post": {"data": null}}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if blog.CurrentPost != nil {
+		t.Fatalf("Was expecting the current post to be cleared, got %#v", blog.CurrentPost)
+	}
+}
+
+func TestStoreNullAttribute(t *testing.T) {
+	store := jsonapi.NewStore()
+	if err := store.Register(new(Book)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Ingest(strings.NewReader(`{
+		"data": {"type": "books", "id": "1", "attributes": {"description": "x", "author": "Ann"}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Ingest(strings.NewReader(`{
+		"data": {"type": "books", "id": "1", "attributes": {"description": null}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	model, _ := store.Find("books", "1")
+	book := model.(*Book)
+	if book.Description != nil {
+		t.Fatalf("Was expecting the description to be cleared, got %q", *book.Description)
+	}
+	if book.Author != "Ann" {
+		t.Fatalf("Was expecting the author to be kept, got %q", book.Author)
+	}
+}
+
+func TestStoreNulls(t *testing.T) {
+	store := jsonapi.NewStore()
+	if err := store.Register(new(Post)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Ingest(strings.NewReader(`{"data": [null]}`)); err != jsonapi.ErrNullResource {
+		t.Fatalf("Was expecting ErrNullResource, got %v", err)
+	}
+
+	payload := `{"data": {"type": "posts", "id": "1", "relationships": {"comments": {"data": [null]}}}}`
+	if _, err := store.Ingest(strings.NewReader(payload)); err != jsonapi.ErrInvalidLinkage {
+		t.Fatalf("Was expecting ErrInvalidLinkage, got %v", err)
+	}
+}
+
+func TestStoreAtomicIngest(t *testing.T) {
+	store := jsonapi.NewStore()
+	if err := store.Register(new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Ingest(strings.NewReader(`{
+		"data": {"type": "blogs", "id": "1", "attributes": {"title": "Before"}}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second resource fails after the first was merged.
+	_, err := store.Ingest(strings.NewReader(`{
+		"data": [
+			{"type": "blogs", "id": "1", "attributes": {"title": "After"}, "relationships": {"current_post": {"data": {"type": "posts", "id": "7"}}}},
+			{"type": "blogs", "id": "2", "attributes": {"title": true}}
+		]
+	}`))
+	if err == nil {
+		t.Fatal("Was expecting an error")
+	}
+
+	model, _ := store.Find("blogs", "1")
+	if blog := model.(*Blog); blog.Title != "Before" || blog.CurrentPost != nil {
+		t.Fatalf("Was expecting the blog to be left untouched, got %#v", blog)
+	}
+	if _, ok := store.Find("blogs", "2"); ok {
+		t.Fatal("Was expecting the failed blog not to be stored")
+	}
+	if _, ok := store.Find("posts", "7"); ok {
+		t.Fatal("Was expecting the related post not to be stored")
+	}
+}
+
+func TestStoreUnregisteredType(t *testing.T) {
+	store := jsonapi.NewStore()
+	if err := store.Register(new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := store.Ingest(strings.NewReader(`{"data": {"type": "books", "id": "1"}}`))
+	if err != (jsonapi.ErrUnregisteredType{Type: "books"}) {
+		t.Fatalf("Was expecting ErrUnregisteredType, got %v", err)
+	}
+
+	// Linkage of a type the relationship's model isn't registered under
+	// fails, and registers nothing.
+	payload := `{"data": {"type": "blogs", "id": "1", "relationships": {"posts": {"data": [{"type": "books", "id": "1"}]}}}}`
+	if _, err := store.Ingest(strings.NewReader(payload)); err == nil {
+		t.Fatal("Was expecting an error")
+	}
+	if _, ok := store.Find("blogs", "1"); ok {
+		t.Fatal("Was expecting the blog not to be stored")
+	}
+	_, err = store.Ingest(strings.NewReader(`{"data": {"type": "books", "id": "1"}}`))
+	if err != (jsonapi.ErrUnregisteredType{Type: "books"}) {
+		t.Fatalf("Was expecting books to stay unregistered, got %v", err)
+	}
+
+	if err := store.Register(Blog{}); err != jsonapi.ErrUnexpectedType {
+		t.Fatalf("Was expecting ErrUnexpectedType, got %v", err)
+	}
+}
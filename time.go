@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// timeFormat returns the time format option of an attr tag, and the layout
+// given with "layout=", if any. An empty option means unix seconds. Tag
+// options are separated by commas, so a layout can't contain one: the rest of
+// such a layout would follow as options that don't exist.
+func timeFormat(args []string) (option, layout string, err error) {
+	if len(args) < 3 {
+		return "", "", nil
+	}
+
+	for i, arg := range args[2:] {
+		switch {
+		case arg == annotationISO8601, arg == annotationRFC3339,
+			arg == annotationRFC3339Nano, arg == annotationUnixMilli:
+			return arg, "", nil
+		case arg == annotationDate:
+			return arg, dateFormat, nil
+		case strings.HasPrefix(arg, annotationLayout+annotationValueSeperator):
+			for _, rest := range args[i+3:] {
+				if checkAttributeOption(rest) != nil {
+					return "", "", ErrInvalidLayout
+				}
+			}
+			return annotationLayout, arg[len(annotationLayout)+1:], nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// formatTime returns the attribute value of t in the format selected by the
+// attr tag args.
+func formatTime(t time.Time, args []string) (interface{}, error) {
+	option, layout, err := timeFormat(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch option {
+	case annotationISO8601:
+		return t.UTC().Format(iso8601TimeFormat), nil
+	case annotationRFC3339:
+		return t.Format(time.RFC3339), nil
+	case annotationRFC3339Nano:
+		return t.Format(time.RFC3339Nano), nil
+	case annotationUnixMilli:
+		return t.UnixNano() / int64(time.Millisecond), nil
+	case annotationDate, annotationLayout:
+		return t.Format(layout), nil
+	}
+
+	return t.Unix(), nil
+}
+
+// parseTime reads an attribute value in the format selected by the attr tag
+// args.
+func parseTime(attribute interface{}, args []string) (time.Time, error) {
+	option, layout, err := timeFormat(args)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch option {
+	case "":
+		seconds, ok := numericAttribute(attribute)
+		if !ok {
+			return time.Time{}, ErrInvalidTime
+		}
+
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(fraction*float64(time.Second))), nil
+	case annotationUnixMilli:
+		millis, ok := numericAttribute(attribute)
+		if !ok {
+			return time.Time{}, ErrInvalidTime
+		}
+
+		return time.Unix(0, int64(millis)*int64(time.Millisecond)), nil
+	case annotationISO8601:
+		tm, ok := attribute.(string)
+		if !ok {
+			return time.Time{}, ErrInvalidISO8601
+		}
+
+		t, err := time.Parse(iso8601TimeFormat, tm)
+		if err != nil {
+			// Accept fractional seconds and UTC offsets too.
+			if t, err = time.Parse(time.RFC3339Nano, tm); err != nil {
+				return time.Time{}, ErrInvalidISO8601
+			}
+		}
+
+		return t, nil
+	case annotationRFC3339:
+		layout = time.RFC3339
+	case annotationRFC3339Nano:
+		layout = time.RFC3339Nano
+	}
+
+	tm, ok := attribute.(string)
+	if !ok {
+		return time.Time{}, ErrInvalidTimeFormat
+	}
+
+	t, err := time.Parse(layout, tm)
+	if err != nil {
+		return time.Time{}, ErrInvalidTimeFormat
+	}
+
+	return t, nil
+}
+
+func numericAttribute(attribute interface{}) (float64, bool) {
+	switch n := attribute.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+
+	return 0, false
+}
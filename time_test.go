@@ -0,0 +1,133 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestMarshalTimeFormats(t *testing.T) {
+	zone := time.FixedZone("CEST", 2*60*60)
+	millis := time.Date(2016, 8, 17, 8, 27, 12, 345000000, time.UTC)
+	reminder := time.Date(2016, 8, 17, 8, 27, 12, 0, zone)
+	schedule := &Schedule{
+		ID:        1,
+		Starts:    time.Date(2016, 8, 17, 8, 27, 12, 0, zone),
+		Precise:   time.Date(2016, 8, 17, 8, 27, 12, 123456789, zone),
+		Millis:    &millis,
+		Day:       time.Date(2016, 8, 17, 0, 0, 0, 0, time.UTC),
+		Custom:    time.Date(2016, 8, 17, 8, 27, 0, 0, time.UTC),
+		Holidays:  []time.Time{time.Date(2016, 12, 25, 0, 0, 0, 0, time.UTC)},
+		Reminders: []*time.Time{&reminder},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayload(out, schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+		t.Fatal(err)
+	}
+	attributes := jsonData["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+
+	expected := map[string]interface{}{
+		"starts":    "2016-08-17T08:27:12+02:00",
+		"precise":   "2016-08-17T08:27:12.123456789+02:00",
+		"millis":    float64(1471422432345),
+		"day":       "2016-08-17",
+		"custom":    "17/08/2016 08:27",
+		"holidays":  []interface{}{"2016-12-25"},
+		"reminders": []interface{}{"2016-08-17T06:27:12Z"},
+	}
+	if !reflect.DeepEqual(expected, attributes) {
+		t.Fatalf("Was expecting %v, got %v", expected, attributes)
+	}
+
+	decoded := new(Schedule)
+	if err := jsonapi.UnmarshalPayload(out, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !decoded.Starts.Equal(schedule.Starts) {
+		t.Fatalf("Was expecting starts %v, got %v", schedule.Starts, decoded.Starts)
+	}
+	if !decoded.Precise.Equal(schedule.Precise) {
+		t.Fatalf("Was expecting precise %v, got %v", schedule.Precise, decoded.Precise)
+	}
+	if !decoded.Millis.Equal(*schedule.Millis) {
+		t.Fatalf("Was expecting millis %v, got %v", schedule.Millis, decoded.Millis)
+	}
+	if !decoded.Day.Equal(schedule.Day) {
+		t.Fatalf("Was expecting day %v, got %v", schedule.Day, decoded.Day)
+	}
+	if !decoded.Custom.Equal(schedule.Custom) {
+		t.Fatalf("Was expecting custom %v, got %v", schedule.Custom, decoded.Custom)
+	}
+	if len(decoded.Holidays) != 1 || !decoded.Holidays[0].Equal(schedule.Holidays[0]) {
+		t.Fatalf("Was expecting holidays %v, got %v", schedule.Holidays, decoded.Holidays)
+	}
+	if len(decoded.Reminders) != 1 || !decoded.Reminders[0].Equal(reminder) {
+		t.Fatalf("Was expecting reminders %v, got %v", schedule.Reminders, decoded.Reminders)
+	}
+}
+
+func TestUnmarshalISO8601WithOffsetAndFraction(t *testing.T) {
+	in := `{"data": {"type": "timestamps", "id": "1", "attributes": {"timestamp": "2016-08-17T10:27:12.5+02:00"}}}`
+
+	out := new(Timestamp)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := time.Date(2016, 8, 17, 8, 27, 12, 500000000, time.UTC)
+	if !out.Time.Equal(expected) {
+		t.Fatalf("Was expecting %v, got %v", expected, out.Time)
+	}
+}
+
+func TestUnmarshalFractionalUnixSeconds(t *testing.T) {
+	in := `{"data": {"type": "blogs", "id": "1", "attributes": {"created_at": 1436216820.25}}}`
+
+	out := new(Blog)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := time.Unix(1436216820, 250000000)
+	if !out.CreatedAt.Equal(expected) {
+		t.Fatalf("Was expecting %v, got %v", expected, out.CreatedAt)
+	}
+}
+
+func TestUnmarshalInvalidTimeFormat(t *testing.T) {
+	in := `{"data": {"type": "schedules", "id": "1", "attributes": {"day": "17/08/2016"}}}`
+
+	err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Schedule))
+	if err != jsonapi.ErrInvalidTimeFormat {
+		t.Fatalf("Was expecting %v, got %v", jsonapi.ErrInvalidTimeFormat, err)
+	}
+}
+
+func TestTimeLayoutWithComma(t *testing.T) {
+	model := &CommaLayout{ID: "1", Date: time.Date(2016, 8, 17, 0, 0, 0, 0, time.UTC)}
+	if err := jsonapi.MarshalPayload(bytes.NewBuffer(nil), model); err != jsonapi.ErrInvalidLayout {
+		t.Fatalf("Was expecting %v, got %v", jsonapi.ErrInvalidLayout, err)
+	}
+
+	in := `{"data": {"type": "comma-layouts", "id": "1", "attributes": {"date": "Aug 17, 2016"}}}`
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(CommaLayout)); err != jsonapi.ErrInvalidLayout {
+		t.Fatalf("Was expecting %v, got %v", jsonapi.ErrInvalidLayout, err)
+	}
+
+	err := jsonapi.NewRegistry().Register(new(CommaLayout))
+	if invalid, ok := err.(jsonapi.ErrInvalidModel); !ok || invalid.Reason != jsonapi.ErrInvalidLayout.Error() {
+		t.Fatalf("Was expecting the layout to be rejected, got %v", err)
+	}
+}
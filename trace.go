@@ -0,0 +1,134 @@
+package jsonapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The names of the spans started around the phases of a marshal or
+// unmarshal call.
+const (
+	// SpanDecodeJSON covers reading and parsing the JSON document.
+	SpanDecodeJSON = "decode JSON"
+
+	// SpanResolveIncluded covers indexing the "included" array.
+	SpanResolveIncluded = "resolve included"
+
+	// SpanVisitModelGraph covers walking the models and their relationships,
+	// to populate them or to build the document.
+	SpanVisitModelGraph = "visit model graph"
+
+	// SpanEncodeJSON covers serializing and writing the JSON document.
+	SpanEncodeJSON = "encode JSON"
+)
+
+// Tracer starts the spans of the phases of marshal and unmarshal calls, so
+// they can be reported to a tracing system.
+type Tracer interface {
+	// StartSpan starts a span named name, as a child of any span in ctx. The
+	// returned context carries the new span and is passed to the hooks
+	// called during it.
+	StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, Span)
+}
+
+// Span is a phase of a call started by a Tracer.
+type Span interface {
+	// End ends the span with the error the phase failed with, if any.
+	End(err error)
+}
+
+// WithTracer sets the Tracer of an Encoder or Decoder. The default is
+// NoopTracer, which a nil tracer also stands for.
+func WithTracer(tracer Tracer) Option {
+	if tracer == nil {
+		tracer = NoopTracer{}
+	}
+
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// NoopTracer is a Tracer whose spans do nothing.
+type NoopTracer struct{}
+
+// StartSpan returns ctx and a span that does nothing.
+func (NoopTracer) StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(err error) {}
+
+// RecordedSpan is a span recorded by a RecordingTracer.
+type RecordedSpan struct {
+	Name       string
+	Attributes map[string]interface{}
+	Start      time.Time
+	Duration   time.Duration
+	Err        error
+	Ended      bool
+}
+
+// RecordingTracer is a Tracer that keeps the spans it starts in memory, for
+// use in tests. It is safe for concurrent use.
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// StartSpan records the start of a span.
+func (rt *RecordingTracer) StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, Span) {
+	span := &RecordedSpan{Name: name, Attributes: attributes, Start: time.Now()}
+
+	rt.mu.Lock()
+	rt.spans = append(rt.spans, span)
+	rt.mu.Unlock()
+
+	return ctx, &recordingSpan{tracer: rt, span: span}
+}
+
+// Spans returns copies of the spans recorded so far, in the order they were
+// started.
+func (rt *RecordingTracer) Spans() []RecordedSpan {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	spans := make([]RecordedSpan, len(rt.spans))
+	for i, span := range rt.spans {
+		spans[i] = *span
+	}
+
+	return spans
+}
+
+type recordingSpan struct {
+	tracer *RecordingTracer
+	span   *RecordedSpan
+}
+
+func (rs *recordingSpan) End(err error) {
+	rs.tracer.mu.Lock()
+	defer rs.tracer.mu.Unlock()
+
+	rs.span.Duration = time.Since(rs.span.Start)
+	rs.span.Err = err
+	rs.span.Ended = true
+}
+
+// trace runs f in a span started with the walk's tracer. The hooks called
+// by f receive the span's context.
+func (w *walk) trace(name string, attributes map[string]interface{}, f func() error) error {
+	parent := w.ctx
+	ctx, span := w.opts.tracer.StartSpan(parent, name, attributes)
+
+	w.ctx = ctx
+	err := f()
+	w.ctx = parent
+
+	span.End(err)
+
+	return err
+}
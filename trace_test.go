@@ -0,0 +1,87 @@
+package jsonapi_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func spanNames(spans []jsonapi.RecordedSpan) []string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return names
+}
+
+func TestTracer_marshalAndUnmarshal(t *testing.T) {
+	tracer := new(jsonapi.RecordingTracer)
+	runtime := jsonapi.NewRuntime().WithTracer(tracer)
+
+	out := bytes.NewBuffer(nil)
+	if err := runtime.MarshalPayload(out, testBlog()); err != nil {
+		t.Fatal(err)
+	}
+	if err := runtime.UnmarshalPayload(out, new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := tracer.Spans()
+	expected := []string{
+		jsonapi.SpanVisitModelGraph,
+		jsonapi.SpanEncodeJSON,
+		jsonapi.SpanDecodeJSON,
+		jsonapi.SpanResolveIncluded,
+		jsonapi.SpanVisitModelGraph,
+	}
+	if e, a := expected, spanNames(spans); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting spans %v, got %v", e, a)
+	}
+
+	for _, span := range spans {
+		if !span.Ended || span.Err != nil {
+			t.Fatalf("Was expecting span %s to have ended without error, got %+v", span.Name, span)
+		}
+	}
+
+	if e, a := 5, spans[3].Attributes["included"]; e != a {
+		t.Fatalf("Was expecting %v included resources, got %v", e, a)
+	}
+}
+
+func TestTracer_spanError(t *testing.T) {
+	tracer := new(jsonapi.RecordingTracer)
+
+	err := jsonapi.NewDecoder(strings.NewReader("{"), jsonapi.WithTracer(tracer)).Decode(new(Blog))
+	if err == nil {
+		t.Fatal("Was expecting an error")
+	}
+
+	spans := tracer.Spans()
+	if e, a := []string{jsonapi.SpanDecodeJSON}, spanNames(spans); !reflect.DeepEqual(e, a) {
+		t.Fatalf("Was expecting spans %v, got %v", e, a)
+	}
+	if spans[0].Err != err {
+		t.Fatalf("Was expecting the span to end with %v, got %v", err, spans[0].Err)
+	}
+}
+
+func TestTracer_noop(t *testing.T) {
+	if err := jsonapi.NewEncoder(bytes.NewBuffer(nil), jsonapi.WithTracer(jsonapi.NoopTracer{})).Encode(testBlog()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTracer_nil(t *testing.T) {
+	if err := jsonapi.NewEncoder(bytes.NewBuffer(nil), jsonapi.WithTracer(nil)).Encode(testBlog()); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := `{"data": {"type": "blogs", "id": "1", "attributes": {"title": "Title"}}}`
+	if err := jsonapi.NewDecoder(strings.NewReader(payload), jsonapi.WithTracer(nil)).Decode(new(Blog)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,266 @@
+package jsonapi
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	validationStatus = "422"
+	validationTitle  = "Invalid Attribute"
+)
+
+// Validator is used to validate a model once it has been unmarshalled.
+// UnmarshalPayload and UnmarshalManyPayload invoke it for every resource they
+// decode, including related and included resources, after the tag based
+// validations have passed.
+//
+// Returning a *ErrorObject or ValidationErrors gives full control over the
+// error objects reported. A Source.Pointer relative to the resource, e.g.
+// "/attributes/title", is prefixed with the resource's pointer in the
+// payload; a missing Source points at the resource itself. Any other error is
+// wrapped in a 422 ErrorObject.
+type Validator interface {
+	JSONAPIValidate() error
+}
+
+// ValidationErrors is returned by UnmarshalPayload and UnmarshalManyPayload
+// when a decoded resource fails validation. Each ErrorObject has a 422 status
+// and a Source.Pointer that targets the offending member, so the slice can be
+// passed straight to MarshalErrors.
+type ValidationErrors []*ErrorObject
+
+// Error implements the `Error` interface.
+func (ve ValidationErrors) Error() string {
+	details := make([]string, len(ve))
+	for i, e := range ve {
+		detail := e.Detail
+		if detail == "" {
+			detail = e.Title
+		}
+		if e.Source != nil && e.Source.Pointer != "" {
+			detail = fmt.Sprintf("%s: %s", e.Source.Pointer, detail)
+		}
+		details[i] = detail
+	}
+
+	return "jsonapi: validation failed: " + strings.Join(details, "; ")
+}
+
+// validateNode runs the validation tag options on model's attributes and then
+// its Validator implementation, if any. A partial update leaves out
+// "required", and the Validator of a model a partial update is applied onto
+// runs after the merge instead.
+func validateNode(data *ResourceObj, model reflect.Value, pointer string, required, validator bool) error {
+	var errs ValidationErrors
+
+	modelValue := model.Elem()
+	modelType := modelValue.Type()
+
+	for i := 0; i < modelValue.NumField(); i++ {
+		tag := modelType.Field(i).Tag.Get(annotationJSONAPI)
+		if tag == "" {
+			continue
+		}
+
+		args := strings.Split(tag, annotationSeperator)
+		if len(args) < 3 || args[0] != annotationAttribute {
+			continue
+		}
+
+		attrPointer := fmt.Sprintf("%s/attributes/%s", pointer, args[1])
+		attribute := data.Attributes[args[1]]
+		fieldValue := reflect.Indirect(modelValue.Field(i))
+
+		for _, option := range args[2:] {
+			rule, param := option, ""
+			if idx := strings.Index(option, annotationValueSeperator); idx >= 0 {
+				rule, param = option[:idx], option[idx+1:]
+			}
+
+			if rule == annotationRequired {
+				if required && attribute == nil {
+					errs = append(errs, newValidationError(rule, attrPointer,
+						fmt.Sprintf("%s is required", args[1])))
+				}
+				continue
+			}
+
+			// Absent and null attributes are only subject to "required".
+			if attribute == nil || !fieldValue.IsValid() {
+				continue
+			}
+
+			detail, err := checkAttribute(rule, param, fieldValue)
+			if err != nil {
+				return err
+			}
+			if detail != "" {
+				errs = append(errs, newValidationError(rule, attrPointer,
+					fmt.Sprintf("%s %s", args[1], detail)))
+			}
+		}
+	}
+
+	if validator && len(errs) == 0 {
+		return validateModel(model, pointer)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateModel runs the Validator implementation of model, if any.
+func validateModel(model reflect.Value, pointer string) error {
+	if validator, ok := model.Interface().(Validator); ok {
+		if err := validator.JSONAPIValidate(); err != nil {
+			return ValidationErrors(validatorErrors(err, pointer))
+		}
+	}
+
+	return nil
+}
+
+// checkAttribute applies a single validation rule to value, returning a
+// description of the failure or "" if the value is valid. Unknown rules are
+// ignored so that options such as "omitempty" pass through.
+func checkAttribute(rule, param string, value reflect.Value) (string, error) {
+	switch rule {
+	case annotationMin, annotationMax:
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", ErrBadJSONAPIStructTag
+		}
+
+		n, isLength, ok := measure(value)
+		if !ok {
+			return "", nil
+		}
+
+		var comparison string
+		if rule == annotationMin && n < bound {
+			comparison = "at least"
+		} else if rule == annotationMax && n > bound {
+			comparison = "at most"
+		} else {
+			return "", nil
+		}
+
+		if isLength {
+			return fmt.Sprintf("must have a length of %s %s", comparison, param), nil
+		}
+		return fmt.Sprintf("must be %s %s", comparison, param), nil
+	case annotationMaxLen:
+		bound, err := strconv.Atoi(param)
+		if err != nil {
+			return "", ErrBadJSONAPIStructTag
+		}
+
+		n, isLength, ok := measure(value)
+		if !ok || !isLength || int(n) <= bound {
+			return "", nil
+		}
+		return fmt.Sprintf("must be no longer than %d", bound), nil
+	case annotationEnum:
+		actual := fmt.Sprint(value.Interface())
+		allowed := strings.Split(param, annotationEnumSeperator)
+		for _, a := range allowed {
+			if a == actual {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(allowed, ", ")), nil
+	case annotationFormat:
+		if param != formatEmail {
+			return "", ErrBadJSONAPIStructTag
+		}
+		if value.Kind() != reflect.String {
+			return "", nil
+		}
+
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be a valid email address", nil
+		}
+	}
+
+	return "", nil
+}
+
+// measure returns the number that min and max compare against: the value of
+// a number, or the length of a string, slice, array or map.
+func measure(value reflect.Value) (n float64, isLength bool, ok bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), true, true
+	}
+
+	return 0, false, false
+}
+
+func newValidationError(code, pointer, detail string) *ErrorObject {
+	return &ErrorObject{
+		Status: validationStatus,
+		Code:   code,
+		Title:  validationTitle,
+		Detail: detail,
+		Source: &ErrorSource{Pointer: pointer},
+	}
+}
+
+// validatorErrors converts the error returned by a Validator into error
+// objects whose pointers are anchored at the resource.
+func validatorErrors(err error, pointer string) []*ErrorObject {
+	var objects []*ErrorObject
+	switch e := err.(type) {
+	case ValidationErrors:
+		objects = e
+	case *ErrorObject:
+		objects = []*ErrorObject{e}
+	default:
+		return []*ErrorObject{{
+			Status: validationStatus,
+			Title:  "Invalid Resource",
+			Detail: err.Error(),
+			Source: &ErrorSource{Pointer: pointer},
+		}}
+	}
+
+	anchored := make([]*ErrorObject, len(objects))
+	for i, o := range objects {
+		object := *o
+		if object.Status == "" {
+			object.Status = validationStatus
+		}
+
+		switch {
+		case object.Source == nil:
+			object.Source = &ErrorSource{Pointer: pointer}
+		case strings.HasPrefix(object.Source.Pointer, "/attributes/"),
+			strings.HasPrefix(object.Source.Pointer, "/relationships/"):
+			source := *object.Source
+			source.Pointer = pointer + source.Pointer
+			object.Source = &source
+		}
+
+		anchored[i] = &object
+	}
+
+	return anchored
+}
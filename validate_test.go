@@ -0,0 +1,145 @@
+package jsonapi_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheeryfella/jsonapi"
+)
+
+func TestUnmarshalValidatesTagOptions(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "authors",
+			"id": "1",
+			"attributes": {
+				"name": "A",
+				"email": "not an email",
+				"age": 12,
+				"role": "owner",
+				"tags": ["a", "b", "c"]
+			}
+		}
+	}`
+
+	err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Author))
+	errs, ok := err.(jsonapi.ValidationErrors)
+	if !ok {
+		t.Fatalf("Was expecting ValidationErrors, got %T: %v", err, err)
+	}
+
+	expected := map[string]string{
+		"/data/attributes/name":  "min",
+		"/data/attributes/email": "format",
+		"/data/attributes/age":   "min",
+		"/data/attributes/role":  "enum",
+		"/data/attributes/tags":  "max",
+	}
+	actual := map[string]string{}
+	for _, e := range errs {
+		if e.Status != "422" {
+			t.Fatalf("Was expecting a 422 status, got %s", e.Status)
+		}
+		actual[e.Source.Pointer] = e.Code
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Was expecting %v, got %v", expected, actual)
+	}
+}
+
+func TestUnmarshalValidatesRequired(t *testing.T) {
+	in := `{"data": {"type": "authors", "id": "1", "attributes": {"name": null}}}`
+
+	err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Author))
+	errs, ok := err.(jsonapi.ValidationErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Was expecting a single validation error, got %v", err)
+	}
+	if e, a := "/data/attributes/name", errs[0].Source.Pointer; e != a {
+		t.Fatalf("Was expecting pointer %s, got %s", e, a)
+	}
+	if e, a := "required", errs[0].Code; e != a {
+		t.Fatalf("Was expecting code %s, got %s", e, a)
+	}
+}
+
+func TestUnmarshalWithoutChecks(t *testing.T) {
+	in := `{"data": {"type": "authors", "id": "1", "attributes": {"name": null, "age": 12}}}`
+
+	author := new(Author)
+	dec := jsonapi.NewDecoder(strings.NewReader(in), jsonapi.WithChecks(false))
+	if err := dec.Decode(author); err != nil {
+		t.Fatalf("Was not expecting validation without checks, got %v", err)
+	}
+	if author.Age == nil || *author.Age != 12 {
+		t.Fatalf("Was expecting age 12, got %v", author.Age)
+	}
+}
+
+func TestUnmarshalValidatesIncluded(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "authors",
+			"id": "1",
+			"attributes": {"name": "Ann"},
+			"relationships": {
+				"editor": {"data": {"type": "authors", "id": "2"}}
+			}
+		},
+		"included": [
+			{"type": "authors", "id": "2", "attributes": {"name": "Bob", "role": "admin"}}
+		]
+	}`
+
+	err := jsonapi.UnmarshalPayload(strings.NewReader(in), new(Author))
+	errs, ok := err.(jsonapi.ValidationErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Was expecting a single validation error, got %v", err)
+	}
+	if e, a := "/included/0/attributes/email", errs[0].Source.Pointer; e != a {
+		t.Fatalf("Was expecting pointer %s, got %s", e, a)
+	}
+	if e, a := "422", errs[0].Status; e != a {
+		t.Fatalf("Was expecting status %s, got %s", e, a)
+	}
+}
+
+func TestUnmarshalSkipsValidationOfResourceIdentifiers(t *testing.T) {
+	in := `{
+		"data": {
+			"type": "authors",
+			"id": "1",
+			"attributes": {"name": "Ann"},
+			"relationships": {
+				"editor": {"data": {"type": "authors", "id": "2"}}
+			}
+		}
+	}`
+
+	out := new(Author)
+	if err := jsonapi.UnmarshalPayload(strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Editor == nil || out.Editor.ID != "2" {
+		t.Fatal("Was expecting the editor to be set")
+	}
+}
+
+func TestUnmarshalManyValidates(t *testing.T) {
+	in := `{
+		"data": [
+			{"type": "authors", "id": "1", "attributes": {"name": "Ann"}},
+			{"type": "authors", "id": "2", "attributes": {"name": "Bob", "email": "bob@"}}
+		]
+	}`
+
+	_, err := jsonapi.UnmarshalManyPayload(strings.NewReader(in), reflect.TypeOf(new(Author)))
+	errs, ok := err.(jsonapi.ValidationErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Was expecting a single validation error, got %v", err)
+	}
+	if e, a := "/data/1/attributes/email", errs[0].Source.Pointer; e != a {
+		t.Fatalf("Was expecting pointer %s, got %s", e, a)
+	}
+}